@@ -5,32 +5,83 @@ import (
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"sort"
+	"sync"
 	"time"
 )
 
 type SamplingConfig = zap.SamplingConfig
 
+type AsyncConfig = cores.AsyncConfig
+
+type RedactConfig = cores.RedactConfig
+
+type FlightRecorderConfig = cores.FlightRecorderConfig
+
+type RateLimitConfig = cores.RateLimitConfig
+
+type DedupConfig = cores.DedupConfig
+
+// Config describes a Logger. Core is ignored when Cores is set, whose cores
+// are teed together. Level applies before the cores, so the Level and MaxLevel
+// of a core can only narrow it: a core Level below Level has no effect.
 type Config struct {
-	Name          string            `json:"name" yaml:"name"`
-	Level         Level             `json:"level" yaml:"level"`
-	LazyDisabled  bool              `json:"lazyDisabled" yaml:"lazyDisabled"`
-	AddCaller     bool              `json:"addCaller" yaml:"addCaller"`
-	StackLevel    *Level            `json:"stackLevel" yaml:"stackLevel"`
-	CallerSkip    int               `json:"callerSkip" yaml:"callerSkip"`
-	FormatEnabled bool              `json:"formatEnabled" yaml:"formatEnabled"`
-	ContextFields map[string]string `json:"contextFields" yaml:"contextFields"`
-	Sampling      *SamplingConfig   `json:"sampling" yaml:"sampling"`
-	InitialFields map[string]any    `json:"initialFields" yaml:"initialFields"`
-	Core          CoreConfig        `json:"core" yaml:"core"`
+	Name            string                `json:"name" yaml:"name"`
+	NameSeparator   string                `json:"nameSeparator" yaml:"nameSeparator"`
+	Level           Level                 `json:"level" yaml:"level"`
+	NameLevels      map[string]Level      `json:"nameLevels" yaml:"nameLevels"`
+	Development     bool                  `json:"development" yaml:"development"`
+	LazyDisabled    bool                  `json:"lazyDisabled" yaml:"lazyDisabled"`
+	AddCaller       bool                  `json:"addCaller" yaml:"addCaller"`
+	StackLevel      *Level                `json:"stackLevel" yaml:"stackLevel"`
+	CallerSkip      int                   `json:"callerSkip" yaml:"callerSkip"`
+	FormatEnabled   bool                  `json:"formatEnabled" yaml:"formatEnabled"`
+	MsgTemplateKey  string                `json:"msgTemplateKey" yaml:"msgTemplateKey"`
+	MsgPlaceholders bool                  `json:"msgPlaceholders" yaml:"msgPlaceholders"`
+	ContextFields   map[string]string     `json:"contextFields" yaml:"contextFields"`
+	Sampling        *SamplingConfig       `json:"sampling" yaml:"sampling"`
+	RateLimit       *RateLimitConfig      `json:"rateLimit" yaml:"rateLimit"`
+	Dedup           *DedupConfig          `json:"dedup" yaml:"dedup"`
+	Async           *AsyncConfig          `json:"async" yaml:"async"`
+	Redact          *RedactConfig         `json:"redact" yaml:"redact"`
+	FlightRecorder  *FlightRecorderConfig `json:"flightRecorder" yaml:"flightRecorder"`
+	InitialFields   map[string]any        `json:"initialFields" yaml:"initialFields"`
+	Core            CoreConfig            `json:"core" yaml:"core"`
+	Cores           []CoreConfig          `json:"cores" yaml:"cores"`
 }
 
-func (c *Config) buildOptions() []Option {
+func (c *Config) buildOptions() ([]Option, error) {
 	opts := make([]Option, 0, 10)
 
 	if c.Name != "" {
 		opts = append(opts, WithName(c.Name))
 	}
 
+	if c.NameSeparator != "" {
+		opts = append(opts, WithNameSeparator(c.NameSeparator))
+	}
+
+	if c.Development {
+		opts = append(opts, Development())
+	}
+
+	if c.Async != nil {
+		opts = append(opts, WrapCore(func(core Core) Core {
+			return cores.NewAsyncCore(core, *c.Async)
+		}))
+	}
+
+	if c.FlightRecorder != nil {
+		opts = append(opts, WithFlightRecorder(*c.FlightRecorder))
+	}
+
+	if c.Redact != nil {
+		redactor, err := cores.NewRedactor(*c.Redact)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithRedact(redactor))
+	}
+
 	if c.LazyDisabled {
 		opts = append(opts, WrapCore(func(core Core) Core {
 			return cores.NewLazyCore(core)
@@ -51,6 +102,14 @@ func (c *Config) buildOptions() []Option {
 		opts = append(opts, WithFormatEnabled())
 	}
 
+	if c.MsgTemplateKey != "" {
+		opts = append(opts, WithMsgTemplateKey(c.MsgTemplateKey))
+	}
+
+	if c.MsgPlaceholders {
+		opts = append(opts, WithMsgPlaceholders(true))
+	}
+
 	if len(c.ContextFields) > 0 {
 		keys := make([]string, 0, len(c.ContextFields))
 		for k := range c.ContextFields {
@@ -60,7 +119,7 @@ func (c *Config) buildOptions() []Option {
 
 		contextHandlers := make([]ContextHandler, 0, len(c.ContextFields))
 		for _, k := range keys {
-			contextHandlers = append(contextHandlers, BuildContextHandler(k, c.ContextFields[k]))
+			contextHandlers = append(contextHandlers, buildContextHandlerByName(k, c.ContextFields[k]))
 		}
 		opts = append(opts, WithContextHandlers(contextHandlers...))
 	}
@@ -98,16 +157,88 @@ func (c *Config) buildOptions() []Option {
 		}))
 	}
 
-	return opts
+	if c.RateLimit != nil {
+		opts = append(opts, WrapCore(func(core Core) Core {
+			return cores.NewRateLimitCore(core, *c.RateLimit)
+		}))
+	}
+
+	if c.Dedup != nil {
+		opts = append(opts, WrapCore(func(core Core) Core {
+			return cores.NewDedupCore(core, *c.Dedup)
+		}))
+	}
+
+	return opts, nil
+}
+
+// coreLevel lets through every level that the logger level or any name
+// override enables, the logger does the precise filtering.
+func coreLevel(lvl AtomicLevel, nameLevels *NameLevels) LevelEnabler {
+	return LevelEnablerFunc(func(l Level) bool {
+		if min, ok := nameLevels.minLevel(); ok && l >= min {
+			return true
+		}
+		return lvl.Enabled(l)
+	})
+}
+
+// buildCore builds Core, or a tee of all Cores when any are configured. The
+// returned function closes their sinks.
+func (c *Config) buildCore(lvl LevelEnabler) (Core, func(), error) {
+	if len(c.Cores) == 0 {
+		return c.Core.build(lvl)
+	}
+
+	cs := make([]Core, 0, len(c.Cores))
+	closers := make([]func(), 0, len(c.Cores))
+	for i := range c.Cores {
+		core, closeSinks, err := c.Cores[i].build(lvl)
+		if err != nil {
+			for _, closeSinks := range closers {
+				closeSinks()
+			}
+			return nil, nil, err
+		}
+		cs = append(cs, core)
+		closers = append(closers, closeSinks)
+	}
+	return zapcore.NewTee(cs...), func() {
+		for _, closeSinks := range closers {
+			closeSinks()
+		}
+	}, nil
 }
 
 func (c *Config) Build(opts ...Option) (*Logger, error) {
-	core, err := c.Core.Build(c.Level)
+	lvl := NewAtomicLevelAt(c.Level)
+	nameLevels, err := NewNameLevels(c.NameLevels)
+	if err != nil {
+		return nil, err
+	}
+	coreLvl := coreLevel(lvl, nameLevels)
+	if c.FlightRecorder != nil {
+		// The flight recorder applies the level of the logger and replays the
+		// other entries through the cores, which must accept them.
+		coreLvl = LevelDebug
+	}
+	// The options are built first, so that no sink is left open when they
+	// are invalid.
+	options, err := c.buildOptions()
+	if err != nil {
+		return nil, err
+	}
+	core, closeSinks, err := c.buildCore(coreLvl)
 	if err != nil {
 		return nil, err
 	}
+	var closeOnce sync.Once
 
-	return NewLogger(core, c.buildOptions()...).WithOptions(opts...), nil
+	return NewLogger(core, WithLevel(lvl), WithNameLevels(nameLevels), withCloseSinks(func() {
+		closeOnce.Do(closeSinks)
+	})).
+		WithOptions(options...).
+		WithOptions(opts...), nil
 }
 
 func NewDefaultConfig() *Config {
@@ -1,7 +1,22 @@
 package glog
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/ace-zhaoy/glog/cores"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 	"testing"
+	"time"
 )
 
 func TestConfig_Build(t *testing.T) {
@@ -38,7 +53,10 @@ func TestConfig_Build(t *testing.T) {
 
 func TestConfig_buildOptions(t *testing.T) {
 	cfg := &Config{}
-	opts := cfg.buildOptions()
+	opts, err := cfg.buildOptions()
+	if err != nil {
+		t.Error("Expected no error, but got", err)
+	}
 	if len(opts) != 0 {
 		t.Error("Expected no options, but got", opts)
 	}
@@ -49,8 +67,457 @@ func TestConfig_buildOptions(t *testing.T) {
 			"key2": "value2",
 		},
 	}
-	opts = cfg.buildOptions()
+	opts, err = cfg.buildOptions()
+	if err != nil {
+		t.Error("Expected no error, but got", err)
+	}
 	if len(opts) != 1 {
 		t.Error("Expected one option, but got", opts)
 	}
 }
+
+func TestConfig_BuildAsync(t *testing.T) {
+	cfg := NewDefaultConfig()
+	cfg.Sampling = nil
+	cfg.Async = &AsyncConfig{QueueSize: 16}
+	logger, err := cfg.Build()
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	if _, ok := logger.core.(*cores.AsyncCore); !ok {
+		t.Errorf("Expected core to be an AsyncCore, but got %T", logger.core)
+	}
+	if err = logger.Sync(); err != nil && !errors.Is(err, syscall.EINVAL) && !errors.Is(err, syscall.ENOTTY) {
+		t.Errorf("Expected Sync to drain the queue, but got %v", err)
+	}
+}
+
+func TestConfig_BuildAsyncPanic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	cfg := &Config{
+		Level: LevelDebug,
+		Async: &AsyncConfig{QueueSize: 16, Overflow: cores.OverflowBlock},
+		Core:  CoreConfig{Encoding: "json", EncoderConfig: EncoderConfig{MessageKey: "msg"}, OutputPaths: []string{path}},
+	}
+	logger, err := cfg.Build()
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("Expected a panic with the message, but got %v", r)
+			}
+		}()
+		for i := 0; i < 200; i++ {
+			logger.Info("queued")
+		}
+		logger.Panic("boom")
+	}()
+
+	expected := strings.Repeat("{\"msg\":\"queued\"}\n", 200) + "{\"msg\":\"boom\"}\n"
+	if got := readFile(t, path); got != expected {
+		t.Errorf("Expected the queued entries and the panic entry to be written, but got %q", got)
+	}
+}
+
+func TestConfig_BuildAtomicLevel(t *testing.T) {
+	cfg := NewDefaultConfig()
+	cfg.Level = LevelInfo
+	logger, err := cfg.Build()
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	if logger.Level() != LevelInfo {
+		t.Errorf("Expected level to be info, but got %v", logger.Level())
+	}
+
+	logger.SetLevel(LevelDebug)
+	if !logger.core.Enabled(LevelDebug) {
+		t.Error("Expected SetLevel to lower the level of the core")
+	}
+}
+
+func TestConfig_BuildNameLevels(t *testing.T) {
+	cfg := NewDefaultConfig()
+	cfg.Level = LevelInfo
+	cfg.NameLevels = map[string]Level{
+		"db.*":        LevelWarn,
+		"http.client": LevelDebug,
+	}
+	logger, err := cfg.Build()
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	if logger.Named("db").Named("query").Enabled(LevelInfo) {
+		t.Error("Expected info to be disabled for db.query")
+	}
+	if !logger.Named("http").Named("client").Enabled(LevelDebug) {
+		t.Error("Expected debug to be enabled for http.client")
+	}
+	if logger.Enabled(LevelDebug) {
+		t.Error("Expected debug to be disabled for the root logger")
+	}
+
+	cfg.NameLevels = map[string]Level{"[": LevelWarn}
+	if _, err = cfg.Build(); err == nil {
+		t.Error("Expected error for malformed name pattern")
+	}
+
+	cfg.NameSeparator = "/"
+	cfg.NameLevels = map[string]Level{"db/*": LevelWarn}
+	logger, err = cfg.Build()
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	if logger.Named("db").Named("query").Enabled(LevelInfo) {
+		t.Error("Expected info to be disabled for db/query")
+	}
+}
+
+func TestConfig_BuildCores(t *testing.T) {
+	dir := t.TempDir()
+	consolePath := filepath.Join(dir, "console.log")
+	errorPath := filepath.Join(dir, "error.log")
+
+	var cfg Config
+	err := json.Unmarshal([]byte(`{
+		"level": "debug",
+		"cores": [
+			{"maxLevel": "warn", "encoding": "console", "encoderConfig": {"messageKey": "msg", "levelKey": "level", "levelEncoder": "capital"}, "outputPaths": [`+strconv.Quote(consolePath)+`]},
+			{"level": "error", "encoding": "json", "encoderConfig": {"messageKey": "msg"}, "outputPaths": [`+strconv.Quote(errorPath)+`]}
+		]
+	}`), &cfg)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	logger, err := cfg.Build()
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	logger.Info("info message", "key", "value")
+	logger.Error("error message")
+	_ = logger.Sync()
+
+	console, _ := os.ReadFile(consolePath)
+	if string(console) != "INFO\tinfo message\t{\"key\": \"value\"}\n" {
+		t.Errorf("Unexpected console output: %q", console)
+	}
+	errorOutput, _ := os.ReadFile(errorPath)
+	if string(errorOutput) != "{\"msg\":\"error message\"}\n" {
+		t.Errorf("Unexpected error output: %q", errorOutput)
+	}
+
+	cfg.Cores[1].Encoding = "unsupported"
+	if _, err = cfg.Build(); err == nil {
+		t.Error("Expected error for unsupported encoding")
+	}
+}
+
+func TestConfig_BuildRedact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	var cfg Config
+	err := json.Unmarshal([]byte(`{
+		"level": "debug",
+		"initialFields": {"apiKey": "k-123"},
+		"redact": {"keys": ["password", "api*"], "detectors": ["email"], "strategy": "keepLast", "keepLast": 2},
+		"core": {"encoding": "json", "encoderConfig": {"messageKey": "msg"}, "outputPaths": [`+strconv.Quote(path)+`]}
+	}`), &cfg)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	logger, err := cfg.Build()
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	logger.With("password", "hunter2").Info("login", "user", "alice@example.com")
+	_ = logger.Sync()
+
+	output, _ := os.ReadFile(path)
+	if string(output) != "{\"msg\":\"login\",\"apiKey\":\"****23\",\"password\":\"****r2\",\"user\":\"****om\"}\n" {
+		t.Errorf("Unexpected output: %q", output)
+	}
+
+	cfg.Redact.Detectors = []string{"unknown"}
+	if _, err = cfg.Build(); err == nil {
+		t.Error("Expected error for unknown detector")
+	}
+}
+
+func TestConfig_BuildRedactPlaceholders(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	cfg := &Config{
+		Level:           LevelDebug,
+		MsgPlaceholders: true,
+		Redact:          &RedactConfig{Keys: []string{"password"}, Detectors: []string{"email"}},
+		Core:            CoreConfig{Encoding: "json", EncoderConfig: EncoderConfig{MessageKey: "msg"}, OutputPaths: []string{path}},
+	}
+	logger, err := cfg.Build()
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	logger.Info("login {user} with {password}", "user", "alice@example.com", "password", "hunter2")
+	logger.With("password", "s3cret").Info("retry with {password}")
+	_ = logger.Sync()
+
+	expected := "{\"msg\":\"login **** with ****\",\"user\":\"****\",\"password\":\"****\",\"msg_template\":\"login {user} with {password}\"}\n" +
+		"{\"msg\":\"retry with ****\",\"password\":\"****\",\"msg_template\":\"retry with {password}\"}\n"
+	if got := readFile(t, path); got != expected {
+		t.Errorf("Expected redacted values in the message, but got %q", got)
+	}
+}
+
+func TestConfig_BuildRateLimit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	var cfg Config
+	err := json.Unmarshal([]byte(`{
+		"level": "debug",
+		"rateLimit": {"burst": 2, "keyField": "user_id"},
+		"core": {"encoding": "json", "encoderConfig": {"messageKey": "msg"}, "outputPaths": [`+strconv.Quote(path)+`]}
+	}`), &cfg)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	logger, err := cfg.Build()
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		logger.Info("request", "user_id", "a", "i", i)
+	}
+	logger.Info("request", "user_id", "b", "i", 0)
+	_ = logger.Sync()
+
+	output, _ := os.ReadFile(path)
+	expected := "{\"msg\":\"request\",\"user_id\":\"a\",\"i\":0}\n" +
+		"{\"msg\":\"request\",\"user_id\":\"a\",\"i\":1}\n" +
+		"{\"msg\":\"request\",\"user_id\":\"b\",\"i\":0}\n" +
+		"{\"msg\":\"log entries suppressed by rate limit\",\"rate_limit_key\":\"user_id=a\",\"rate_limit_level\":\"info\",\"suppressed\":3}\n"
+	if string(output) != expected {
+		t.Errorf("Unexpected output: %q", output)
+	}
+}
+
+// newRangedCoresConfig returns a config with extra settings and two cores, one
+// for warn and above and one for info and below, and the paths they write to.
+func newRangedCoresConfig(t *testing.T, extra string) (cfg *Config, warnPath, infoPath string) {
+	dir := t.TempDir()
+	warnPath = filepath.Join(dir, "warn.log")
+	infoPath = filepath.Join(dir, "info.log")
+
+	cfg = &Config{}
+	err := json.Unmarshal([]byte(`{
+		"level": "debug",
+		`+extra+`
+		"cores": [
+			{"level": "warn", "encoding": "json", "encoderConfig": {"messageKey": "msg"}, "outputPaths": [`+strconv.Quote(warnPath)+`]},
+			{"maxLevel": "info", "encoding": "json", "encoderConfig": {"messageKey": "msg"}, "outputPaths": [`+strconv.Quote(infoPath)+`]}
+		]
+	}`), cfg)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	return
+}
+
+func readFile(t *testing.T, path string) string {
+	b, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	return string(b)
+}
+
+func TestConfig_BuildAsyncCores(t *testing.T) {
+	cfg, warnPath, infoPath := newRangedCoresConfig(t, `"async": {},`)
+	logger, err := cfg.Build()
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	logger.Info("info message")
+	logger.Error("error message")
+	_ = logger.Sync()
+
+	if got := readFile(t, warnPath); got != "{\"msg\":\"error message\"}\n" {
+		t.Errorf("Unexpected warn output: %q", got)
+	}
+	if got := readFile(t, infoPath); got != "{\"msg\":\"info message\"}\n" {
+		t.Errorf("Unexpected info output: %q", got)
+	}
+}
+
+func TestConfig_BuildWrappedCores(t *testing.T) {
+	tests := map[string]string{
+		"redact":    `"redact": {"keys": ["password"]},`,
+		"rateLimit": `"rateLimit": {"burst": 10},`,
+		"dedup":     `"dedup": {"window": 1000000000},`,
+		"sampling":  `"sampling": {"initial": 10, "thereafter": 10},`,
+		"combined":  `"async": {}, "redact": {"keys": ["password"]}, "rateLimit": {"burst": 10}, "dedup": {"window": 1000000000},`,
+	}
+	for name, extra := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg, warnPath, infoPath := newRangedCoresConfig(t, extra)
+			logger, err := cfg.Build()
+			if err != nil {
+				t.Fatalf("Expected no error, but got %v", err)
+			}
+			logger.Info("info message")
+			logger.Error("error message")
+			_ = logger.Sync()
+
+			if got := readFile(t, warnPath); got != "{\"msg\":\"error message\"}\n" {
+				t.Errorf("Unexpected warn output: %q", got)
+			}
+			if got := readFile(t, infoPath); got != "{\"msg\":\"info message\"}\n" {
+				t.Errorf("Unexpected info output: %q", got)
+			}
+		})
+	}
+}
+
+func TestConfig_BuildFlightRecorderCores(t *testing.T) {
+	for name, extra := range map[string]string{
+		"sync":  `"flightRecorder": {},`,
+		"async": `"flightRecorder": {}, "async": {},`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg, warnPath, infoPath := newRangedCoresConfig(t, extra)
+			cfg.Level = LevelInfo
+			logger, err := cfg.Build()
+			if err != nil {
+				t.Fatalf("Expected no error, but got %v", err)
+			}
+			logger.Debug("debug message")
+			logger.Info("info message")
+			logger.Error("error message")
+			_ = logger.Sync()
+
+			if got := readFile(t, warnPath); got != "{\"msg\":\"error message\"}\n" {
+				t.Errorf("Unexpected warn output: %q", got)
+			}
+			expected := "{\"msg\":\"info message\"}\n" +
+				"{\"msg\":\"debug message\",\"flight_recorder\":true}\n"
+			if got := readFile(t, infoPath); got != expected {
+				t.Errorf("Unexpected info output: %q", got)
+			}
+		})
+	}
+}
+
+func TestConfig_BuildFlightRecorderContextFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	cfg := &Config{
+		Level:          LevelInfo,
+		FlightRecorder: &FlightRecorderConfig{},
+		ContextFields:  map[string]string{"request_id": "request_id"},
+		Core:           CoreConfig{Encoding: "json", EncoderConfig: EncoderConfig{MessageKey: "msg"}, OutputPaths: []string{path}},
+	}
+	logger, err := cfg.Build()
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	ctxA := context.WithValue(NewFlightRecorderContext(context.Background()), "request_id", "a")
+	ctxB := context.WithValue(NewFlightRecorderContext(context.Background()), "request_id", "b")
+	logger.DebugContext(ctxA, "debug a")
+	logger.DebugContext(ctxB, "debug b")
+	logger.ErrorContext(ctxB, "error b")
+	_ = logger.Sync()
+
+	expected := "{\"msg\":\"debug b\",\"request_id\":\"b\",\"flight_recorder\":true}\n" +
+		"{\"msg\":\"error b\",\"request_id\":\"b\"}\n"
+	if got := readFile(t, path); got != expected {
+		t.Errorf("Expected only the entries of the failed request, but got %q", got)
+	}
+}
+
+// openedSinks and closedSinks record the sinks opened with the closetest
+// scheme and those that have been closed.
+var openedSinks, closedSinks sync.Map
+
+type closeTestSink struct {
+	zapcore.WriteSyncer
+	name string
+}
+
+func (s closeTestSink) Close() error {
+	closedSinks.Store(s.name, true)
+	return nil
+}
+
+func init() {
+	err := zap.RegisterSink("closetest", func(u *url.URL) (zap.Sink, error) {
+		openedSinks.Store(u.Opaque, true)
+		return closeTestSink{WriteSyncer: zapcore.AddSync(io.Discard), name: u.Opaque}, nil
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func TestConfig_BuildCoresCloseOnError(t *testing.T) {
+	cfg, _, _ := newRangedCoresConfig(t, "")
+	cfg.Cores[0].OutputPaths = []string{"closetest:first"}
+	cfg.Cores[1].OutputPaths = []string{"unknown-scheme://app.log"}
+
+	if _, err := cfg.Build(); err == nil {
+		t.Fatal("Expected error for an unknown sink scheme")
+	}
+	if _, ok := closedSinks.Load("first"); !ok {
+		t.Error("Expected the sinks of the first core to be closed")
+	}
+}
+
+func TestConfig_BuildClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	cfg := &Config{
+		Level:     LevelDebug,
+		Async:     &AsyncConfig{},
+		RateLimit: &RateLimitConfig{Burst: 1, KeyField: "k", SummaryInterval: time.Hour},
+		Dedup:     &DedupConfig{Window: time.Hour},
+		Core:      CoreConfig{Encoding: "json", EncoderConfig: EncoderConfig{MessageKey: "msg"}, OutputPaths: []string{path, "closetest:close"}},
+	}
+	logger, err := cfg.Build()
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		logger.Info("a")
+	}
+	logger.Info("m1", "k", "x")
+	logger.Info("m2", "k", "x")
+
+	if err = logger.Close(); err != nil {
+		t.Errorf("Expected no error, but got %v", err)
+	}
+	expected := "{\"msg\":\"a\"}\n" +
+		"{\"msg\":\"m1\",\"k\":\"x\"}\n" +
+		"{\"msg\":\"a (repeated 2 times)\",\"repeated\":2}\n" +
+		"{\"msg\":\"log entries suppressed by rate limit\",\"rate_limit_key\":\"k=x\",\"rate_limit_level\":\"info\",\"suppressed\":1}\n"
+	if got := readFile(t, path); got != expected {
+		t.Errorf("Expected Close to write the pending summaries, but got %q", got)
+	}
+	if _, ok := closedSinks.Load("close"); !ok {
+		t.Error("Expected Close to close the sinks")
+	}
+}
+
+func TestConfig_BuildOptionsError(t *testing.T) {
+	cfg := &Config{
+		Redact: &RedactConfig{Detectors: []string{"unknown"}},
+		Core:   CoreConfig{Encoding: "json", OutputPaths: []string{"closetest:options"}},
+	}
+	if _, err := cfg.Build(); err == nil {
+		t.Fatal("Expected error for unknown detector")
+	}
+	if _, ok := openedSinks.Load("options"); ok {
+		t.Error("Expected no sink to be opened for invalid options")
+	}
+}
@@ -0,0 +1,101 @@
+package glog
+
+import (
+	"context"
+	"github.com/ace-zhaoy/glog/cores"
+	"go.uber.org/zap/zapcore"
+	"sync/atomic"
+)
+
+type loggerContextKey struct{}
+
+// contextLogger is the value stored by NewContext.
+type contextLogger struct {
+	logger  *Logger
+	wrapper atomic.Value // *Logger, see WrapperLoggerFromContext
+}
+
+var (
+	_nopLogger      = NewLogger(zapcore.NewNopCore())
+	_fallbackLogger atomic.Value // func() *Logger
+)
+
+// NewContext returns a copy of ctx that carries l.
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, loggerContextKey{}, &contextLogger{logger: l})
+}
+
+func loadContextLogger(ctx context.Context) *contextLogger {
+	if ctx == nil {
+		return nil
+	}
+	c, _ := ctx.Value(loggerContextKey{}).(*contextLogger)
+	if c == nil || c.logger == nil {
+		return nil
+	}
+	return c
+}
+
+// LoggerFromContext returns the logger stored in ctx by NewContext.
+func LoggerFromContext(ctx context.Context) (*Logger, bool) {
+	if c := loadContextLogger(ctx); c != nil {
+		return c.logger, true
+	}
+	return nil, false
+}
+
+// WrapperLoggerFromContext is LoggerFromContext for functions that call the
+// logger on behalf of their own caller, such as those of the log package.
+// The returned logger skips one more frame; it is built once per NewContext
+// rather than on every call.
+func WrapperLoggerFromContext(ctx context.Context) (*Logger, bool) {
+	c := loadContextLogger(ctx)
+	if c == nil {
+		return nil, false
+	}
+	if l, _ := c.wrapper.Load().(*Logger); l != nil {
+		return l, true
+	}
+	l := c.logger.WithOptions(AddCallerSkip(1))
+	c.wrapper.Store(l)
+	return l, true
+}
+
+// FromContext returns the logger stored in ctx, or the fallback logger if
+// there is none. The log package installs its global logger as the fallback;
+// without it a no-op logger is returned.
+func FromContext(ctx context.Context) *Logger {
+	if l, ok := LoggerFromContext(ctx); ok {
+		return l
+	}
+	if f, _ := _fallbackLogger.Load().(func() *Logger); f != nil {
+		return f()
+	}
+	return _nopLogger
+}
+
+// SetFallbackLogger sets the function FromContext uses when ctx carries no logger.
+func SetFallbackLogger(f func() *Logger) {
+	_fallbackLogger.Store(f)
+}
+
+// NewContext enriches the logger with the fields of its context handlers, as
+// WithContext does, and returns a copy of ctx that carries the result.
+// The handlers are not run again when the stored logger is used with a
+// context, so values added to ctx afterwards are not recorded.
+func (l *Logger) NewContext(ctx context.Context) context.Context {
+	log := l.WithContext(ctx)
+	if log != l {
+		log.contextHandlers = nil
+	}
+	return NewContext(ctx, log)
+}
+
+// NewFlightRecorderContext returns a copy of ctx with a flight recorder buffer
+// of its own, used by loggers created with WithFlightRecorder.
+func NewFlightRecorderContext(ctx context.Context) context.Context {
+	return cores.NewFlightRecorderContext(ctx)
+}
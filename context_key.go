@@ -0,0 +1,142 @@
+package glog
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap/zapcore"
+	"sync"
+	"time"
+)
+
+// ContextKey is a typed, collision-free key for values stored in a context.Context.
+// Keys are compared by identity, so two keys created with the same name are distinct.
+type ContextKey[T any] struct {
+	name string
+}
+
+func NewContextKey[T any](name string) *ContextKey[T] {
+	return &ContextKey[T]{name: name}
+}
+
+func (k *ContextKey[T]) Name() string {
+	return k.name
+}
+
+func (k *ContextKey[T]) String() string {
+	return "glog.ContextKey(" + k.name + ")"
+}
+
+// WithValue returns a copy of ctx that carries v under k.
+func (k *ContextKey[T]) WithValue(ctx context.Context, v T) context.Context {
+	return context.WithValue(ctx, k, v)
+}
+
+// Value returns the value stored under k in ctx.
+func (k *ContextKey[T]) Value(ctx context.Context) (v T, ok bool) {
+	if ctx == nil {
+		return
+	}
+	v, ok = ctx.Value(k).(T)
+	return
+}
+
+func (k *ContextKey[T]) handler(alias string) ContextHandler {
+	if alias == "" {
+		return BuildTypedContextHandler(k)
+	}
+	return BuildTypedContextHandler(k, alias)
+}
+
+// BuildTypedContextHandler builds a ContextHandler that records the value of key.
+// alias is an optional alias for the field key, which defaults to the key name.
+func BuildTypedContextHandler[T any](key *ContextKey[T], alias ...string) ContextHandler {
+	fieldName := key.name
+	if len(alias) > 0 && alias[0] != "" {
+		fieldName = alias[0]
+	}
+	return func(ctx context.Context, record *Record) {
+		if v, ok := key.Value(ctx); ok {
+			record.AddFields(typedField(fieldName, v))
+		}
+	}
+}
+
+// typedField picks the Field constructor matching the dynamic type of v.
+func typedField(key string, v any) Field {
+	switch val := v.(type) {
+	case string:
+		return String(key, val)
+	case bool:
+		return Bool(key, val)
+	case time.Duration:
+		return Duration(key, val)
+	case time.Time:
+		return Time(key, val)
+	case int:
+		return Int64(key, int64(val))
+	case int64:
+		return Int64(key, val)
+	case int32:
+		return Int64(key, int64(val))
+	case int16:
+		return Int64(key, int64(val))
+	case int8:
+		return Int64(key, int64(val))
+	case uint:
+		return Uint64(key, uint64(val))
+	case uint64:
+		return Uint64(key, val)
+	case uint32:
+		return Uint64(key, uint64(val))
+	case uint16:
+		return Uint64(key, uint64(val))
+	case uint8:
+		return Uint64(key, uint64(val))
+	case float64:
+		return Float64(key, val)
+	case float32:
+		return Float64(key, float64(val))
+	case zapcore.ObjectMarshaler:
+		return Object(key, val)
+	case fmt.Stringer:
+		return Stringer(key, val)
+	default:
+		return Any(key, val)
+	}
+}
+
+// namedContextKey is implemented by every *ContextKey[T].
+type namedContextKey interface {
+	Name() string
+	handler(alias string) ContextHandler
+}
+
+var (
+	_contextKeyMutex sync.RWMutex
+	_contextKeys     = map[string]namedContextKey{}
+)
+
+// RegisterContextKey makes key available to Config.ContextFields under its name.
+// Names that are not registered are looked up as plain string context keys.
+func RegisterContextKey[T any](key *ContextKey[T]) error {
+	_contextKeyMutex.Lock()
+	defer _contextKeyMutex.Unlock()
+
+	if _, ok := _contextKeys[key.name]; ok {
+		return fmt.Errorf("context key already registered for name %q", key.name)
+	}
+	_contextKeys[key.name] = key
+	return nil
+}
+
+// buildContextHandlerByName builds the handler for a Config.ContextFields entry.
+func buildContextHandlerByName(name, alias string) ContextHandler {
+	_contextKeyMutex.RLock()
+	key, ok := _contextKeys[name]
+	_contextKeyMutex.RUnlock()
+
+	if ok {
+		return key.handler(alias)
+	}
+	return BuildContextHandler(name, alias)
+}
@@ -0,0 +1,105 @@
+package glog
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+	"testing"
+	"time"
+)
+
+type testUser struct {
+	ID string
+}
+
+func (u testUser) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("id", u.ID)
+	return nil
+}
+
+func TestContextKey(t *testing.T) {
+	key := NewContextKey[string]("request_id")
+	other := NewContextKey[string]("request_id")
+
+	ctx := key.WithValue(context.Background(), "123")
+	v, ok := key.Value(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "123", v)
+
+	_, ok = other.Value(ctx)
+	assert.False(t, ok, "Expected keys with the same name not to collide")
+	assert.Nil(t, ctx.Value("request_id"), "Expected typed key not to collide with a string key")
+
+	_, ok = key.Value(nil)
+	assert.False(t, ok, "Expected nil context to have no value")
+	assert.Equal(t, "request_id", key.Name())
+	assert.Equal(t, "glog.ContextKey(request_id)", key.String())
+}
+
+func TestBuildTypedContextHandler(t *testing.T) {
+	now := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+	stringKey := NewContextKey[string]("request_id")
+	intKey := NewContextKey[int]("attempt")
+	uintKey := NewContextKey[uint32]("shard")
+	durationKey := NewContextKey[time.Duration]("budget")
+	timeKey := NewContextKey[time.Time]("deadline")
+	userKey := NewContextKey[testUser]("user")
+	anyKey := NewContextKey[[]string]("tags")
+
+	ctx := context.Background()
+	ctx = stringKey.WithValue(ctx, "123")
+	ctx = intKey.WithValue(ctx, 3)
+	ctx = uintKey.WithValue(ctx, 7)
+	ctx = durationKey.WithValue(ctx, time.Second)
+	ctx = timeKey.WithValue(ctx, now)
+	ctx = userKey.WithValue(ctx, testUser{ID: "u1"})
+	ctx = anyKey.WithValue(ctx, []string{"a"})
+
+	record := NewRecordWithCapacity(7)
+	for _, handler := range []ContextHandler{
+		BuildTypedContextHandler(stringKey, "req_id"),
+		BuildTypedContextHandler(intKey),
+		BuildTypedContextHandler(uintKey),
+		BuildTypedContextHandler(durationKey),
+		BuildTypedContextHandler(timeKey),
+		BuildTypedContextHandler(userKey),
+		BuildTypedContextHandler(anyKey),
+		BuildTypedContextHandler(NewContextKey[string]("missing")),
+	} {
+		handler(ctx, record)
+	}
+
+	assert.Equal(t, []Field{
+		String("req_id", "123"),
+		Int64("attempt", 3),
+		Uint64("shard", 7),
+		Duration("budget", time.Second),
+		Time("deadline", now),
+		Object("user", testUser{ID: "u1"}),
+		Any("tags", []string{"a"}),
+	}, record.Fields())
+}
+
+func TestRegisterContextKey(t *testing.T) {
+	key := NewContextKey[int64]("test_tenant_id")
+	assert.NoError(t, RegisterContextKey(key))
+	assert.Error(t, RegisterContextKey(NewContextKey[string]("test_tenant_id")), "Expected error for duplicate name")
+
+	core := &mockCore{enabled: true}
+	cfg := &Config{ContextFields: map[string]string{
+		"test_tenant_id": "tenant",
+		"request_id":     "",
+	}}
+	opts, err := cfg.buildOptions()
+	assert.NoError(t, err)
+	logger := NewLogger(core, opts...)
+
+	ctx := key.WithValue(context.Background(), 42)
+	ctx = context.WithValue(ctx, "request_id", "abc")
+	logger.InfoContext(ctx, "message")
+
+	assert.Equal(t, []Field{
+		String("request_id", "abc"),
+		Int64("tenant", 42),
+	}, core.fields, "Expected registered typed keys and plain string keys to be resolved")
+}
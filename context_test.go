@@ -0,0 +1,68 @@
+package glog
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewContext(t *testing.T) {
+	logger := NewLogger(&mockCore{enabled: true})
+	ctx := NewContext(context.Background(), logger)
+
+	got, ok := LoggerFromContext(ctx)
+	assert.True(t, ok, "Expected logger to be found in context")
+	assert.Equal(t, logger, got)
+	assert.Equal(t, logger, FromContext(ctx))
+
+	_, ok = LoggerFromContext(context.Background())
+	assert.False(t, ok, "Expected no logger in an empty context")
+	_, ok = LoggerFromContext(nil)
+	assert.False(t, ok, "Expected no logger in a nil context")
+	assert.NotNil(t, NewContext(nil, logger), "Expected nil context to be replaced")
+}
+
+func TestWrapperLoggerFromContext(t *testing.T) {
+	logger := NewLogger(&mockCore{enabled: true})
+	ctx := NewContext(context.Background(), logger)
+
+	got, ok := WrapperLoggerFromContext(ctx)
+	assert.True(t, ok, "Expected logger to be found in context")
+	assert.Equal(t, logger.callerSkip+1, got.callerSkip, "Expected one more caller skip")
+	again, _ := WrapperLoggerFromContext(ctx)
+	assert.Same(t, got, again, "Expected the logger to be built once")
+
+	_, ok = WrapperLoggerFromContext(context.Background())
+	assert.False(t, ok, "Expected no logger in an empty context")
+	_, ok = WrapperLoggerFromContext(NewContext(context.Background(), nil))
+	assert.False(t, ok, "Expected no logger for a nil logger")
+}
+
+func TestFromContext_Fallback(t *testing.T) {
+	old, _ := _fallbackLogger.Load().(func() *Logger)
+	defer SetFallbackLogger(old)
+
+	SetFallbackLogger(nil)
+	assert.Equal(t, _nopLogger, FromContext(context.Background()), "Expected no-op logger without a fallback")
+
+	fallback := NewLogger(&mockCore{enabled: true})
+	SetFallbackLogger(func() *Logger { return fallback })
+	assert.Equal(t, fallback, FromContext(context.Background()), "Expected fallback logger")
+	assert.Equal(t, fallback, FromContext(nil), "Expected fallback logger for a nil context")
+}
+
+func TestLogger_NewContext(t *testing.T) {
+	core := &mockCore{enabled: true}
+	logger := NewLogger(core, WithContextHandlers(BuildContextHandler("request_id")))
+
+	ctx := context.WithValue(context.Background(), "request_id", "123")
+	ctx = logger.NewContext(ctx)
+	assert.Equal(t, []Field{String("request_id", "123")}, core.fields, "Expected context fields to be added to the stored logger")
+
+	core.fields = nil
+	FromContext(ctx).With("user", "ace").InfoContext(ctx, "message")
+	assert.Equal(t, []Field{String("user", "ace")}, core.fields, "Expected context handlers not to run twice")
+
+	plain := NewLogger(core)
+	assert.Equal(t, plain, FromContext(plain.NewContext(context.Background())), "Expected logger without handlers to be stored as is")
+}
@@ -1,7 +1,7 @@
 package glog
 
 import (
-	"fmt"
+	"github.com/ace-zhaoy/glog/rotate"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -10,37 +10,80 @@ type Core = zapcore.Core
 
 type EncoderConfig = zapcore.EncoderConfig
 
+type RotateConfig = rotate.Config
+
+// CoreConfig describes a core. Level and MaxLevel bound the levels it writes
+// among those the logger enables.
 type CoreConfig struct {
+	Level         *Level        `json:"level" yaml:"level"`
+	MaxLevel      *Level        `json:"maxLevel" yaml:"maxLevel"`
 	Encoding      string        `json:"encoding" yaml:"encoding"`
 	EncoderConfig EncoderConfig `json:"encoderConfig" yaml:"encoderConfig"`
 	OutputPaths   []string      `json:"outputPaths" yaml:"outputPaths"`
+	Rotate        *RotateConfig `json:"rotate" yaml:"rotate"`
 }
 
 func (c *CoreConfig) buildEncoder() (zapcore.Encoder, error) {
-	switch c.Encoding {
-	case "json":
-		return zapcore.NewJSONEncoder(c.EncoderConfig), nil
-	case "console":
-		return zapcore.NewConsoleEncoder(c.EncoderConfig), nil
-	default:
-		return nil, fmt.Errorf("unsupported encoding: %s", c.Encoding)
+	return newEncoder(c.Encoding, c.EncoderConfig)
+}
+
+// openSinks opens the outputs of c. The returned function closes them.
+func (c *CoreConfig) openSinks() (zapcore.WriteSyncer, func(), error) {
+	if c.Rotate == nil {
+		return zap.Open(c.OutputPaths...)
+	}
+
+	writer, err := rotate.New(*c.Rotate)
+	if err != nil {
+		return nil, nil, err
+	}
+	closeWriter := func() { _ = writer.Close() }
+	if len(c.OutputPaths) == 0 {
+		return writer, closeWriter, nil
 	}
+
+	sink, closeSink, err := zap.Open(c.OutputPaths...)
+	if err != nil {
+		closeWriter()
+		return nil, nil, err
+	}
+	return zapcore.NewMultiWriteSyncer(sink, writer), func() {
+		closeSink()
+		closeWriter()
+	}, nil
 }
 
-func (c *CoreConfig) openSinks() (zapcore.WriteSyncer, error) {
-	sink, _, err := zap.Open(c.OutputPaths...)
-	return sink, err
+// levelEnabler restricts lvl to the range between Level and MaxLevel.
+func (c *CoreConfig) levelEnabler(lvl LevelEnabler) LevelEnabler {
+	if c.Level == nil && c.MaxLevel == nil {
+		return lvl
+	}
+	return LevelEnablerFunc(func(l Level) bool {
+		if c.Level != nil && l < *c.Level {
+			return false
+		}
+		if c.MaxLevel != nil && l > *c.MaxLevel {
+			return false
+		}
+		return lvl.Enabled(l)
+	})
 }
 
 func (c *CoreConfig) Build(lvl LevelEnabler) (core Core, err error) {
+	core, _, err = c.build(lvl)
+	return
+}
+
+// build is Build that also returns the function closing the sinks of the core.
+func (c *CoreConfig) build(lvl LevelEnabler) (core Core, closeSinks func(), err error) {
 	enc, err := c.buildEncoder()
 	if err != nil {
 		return
 	}
-	sink, err := c.openSinks()
+	sink, closeSinks, err := c.openSinks()
 	if err != nil {
 		return
 	}
-	core = zapcore.NewCore(enc, sink, lvl)
+	core = zapcore.NewCore(enc, sink, c.levelEnabler(lvl))
 	return
 }
@@ -1,8 +1,11 @@
 package glog
 
 import (
+	"github.com/ace-zhaoy/glog/encoders"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap/zapcore"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -21,6 +24,11 @@ func TestCoreConfig_buildEncoder(t *testing.T) {
 	assert.NoError(t, err, "Expected no error when building Console encoder")
 	assert.IsType(t, zapcore.NewConsoleEncoder(zapcore.EncoderConfig{}), enc, "Expected Console encoder")
 
+	cfg.Encoding = "logfmt"
+	enc, err = cfg.buildEncoder()
+	assert.NoError(t, err, "Expected no error when building logfmt encoder")
+	assert.IsType(t, encoders.NewLogfmtEncoder(zapcore.EncoderConfig{}), enc, "Expected logfmt encoder")
+
 	cfg.Encoding = "unsupported"
 	enc, err = cfg.buildEncoder()
 	assert.Error(t, err, "Expected error for unsupported encoding")
@@ -31,12 +39,13 @@ func TestCoreConfig_openSinks(t *testing.T) {
 		OutputPaths: []string{"stdout"},
 	}
 
-	sink, err := cfg.openSinks()
+	sink, closeSinks, err := cfg.openSinks()
 	assert.NoError(t, err, "Expected no error when opening stdout sink")
 	assert.NotNil(t, sink, "Expected valid WriteSyncer sink")
+	closeSinks()
 
 	cfg.OutputPaths = []string{""}
-	sink, err = cfg.openSinks()
+	_, _, err = cfg.openSinks()
 	assert.Error(t, err, "Expected error when opening invalid path sink")
 }
 
@@ -56,3 +65,39 @@ func TestCoreConfig_Build(t *testing.T) {
 	core, err = cfg.Build(lvl)
 	assert.Error(t, err, "Expected error when building Core with unsupported encoding")
 }
+
+func TestCoreConfig_openSinksRotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	cfg := CoreConfig{
+		Rotate: &RotateConfig{Filename: path, MaxSize: 1024},
+	}
+
+	sink, closeSinks, err := cfg.openSinks()
+	assert.NoError(t, err, "Expected no error when opening rotate sink")
+	_, err = sink.Write([]byte("hello\n"))
+	assert.NoError(t, err, "Expected no error when writing to rotate sink")
+	assert.FileExists(t, path, "Expected rotate sink to create the file")
+	closeSinks()
+	_, err = sink.Write([]byte("closed\n"))
+	assert.ErrorIs(t, err, os.ErrClosed, "Expected the close function to close the rotate writer")
+
+	cfg.Rotate.Compress = "zstd"
+	_, _, err = cfg.openSinks()
+	assert.Error(t, err, "Expected error for unsupported compression")
+}
+
+func TestCoreConfig_levelEnabler(t *testing.T) {
+	minLevel, maxLevel := LevelInfo, LevelWarn
+	cfg := CoreConfig{}
+	assert.Equal(t, LevelEnabler(LevelDebug), cfg.levelEnabler(LevelDebug), "Expected level to be unchanged without a range")
+
+	cfg = CoreConfig{Level: &minLevel, MaxLevel: &maxLevel}
+	enabler := cfg.levelEnabler(LevelDebug)
+	assert.False(t, enabler.Enabled(LevelDebug), "Expected debug to be below the range")
+	assert.True(t, enabler.Enabled(LevelInfo), "Expected info to be in the range")
+	assert.True(t, enabler.Enabled(LevelWarn), "Expected warn to be in the range")
+	assert.False(t, enabler.Enabled(LevelError), "Expected error to be above the range")
+
+	enabler = cfg.levelEnabler(LevelWarn)
+	assert.False(t, enabler.Enabled(LevelInfo), "Expected the given level to still apply")
+}
@@ -0,0 +1,278 @@
+package cores
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const DefaultAsyncQueueSize = 1024
+
+var ErrAsyncCoreClosed = errors.New("async core is closed")
+
+// OverflowPolicy decides what an AsyncCore does with an entry when its queue is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock blocks the caller until the queue has room.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest discards the entry being written.
+	OverflowDropNewest
+	// OverflowDropOldest discards the oldest queued entry to make room.
+	OverflowDropOldest
+	// OverflowDropBelowLevel discards entries below AsyncConfig.DropLevel and blocks for the rest.
+	OverflowDropBelowLevel
+)
+
+var overflowPolicyNames = map[OverflowPolicy]string{
+	OverflowBlock:          "block",
+	OverflowDropNewest:     "dropNewest",
+	OverflowDropOldest:     "dropOldest",
+	OverflowDropBelowLevel: "dropBelowLevel",
+}
+
+func (p OverflowPolicy) String() string {
+	if name, ok := overflowPolicyNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("OverflowPolicy(%d)", int(p))
+}
+
+func (p OverflowPolicy) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+func (p *OverflowPolicy) UnmarshalText(text []byte) error {
+	for policy, name := range overflowPolicyNames {
+		if name == string(text) {
+			*p = policy
+			return nil
+		}
+	}
+	return fmt.Errorf("unrecognized overflow policy: %q", text)
+}
+
+type AsyncConfig struct {
+	QueueSize int            `json:"queueSize" yaml:"queueSize"`
+	Overflow  OverflowPolicy `json:"overflow" yaml:"overflow"`
+	DropLevel zapcore.Level  `json:"dropLevel" yaml:"dropLevel"`
+}
+
+// AsyncStats reports the counters of an AsyncCore.
+type AsyncStats struct {
+	Written uint64
+	Dropped uint64
+	Errors  uint64
+}
+
+type asyncEntry struct {
+	core   zapcore.Core
+	ent    zapcore.Entry
+	fields []zapcore.Field
+}
+
+// asyncWriter owns the queue and the background goroutine shared by
+// an AsyncCore and all cores derived from it with With.
+type asyncWriter struct {
+	queue     chan asyncEntry
+	policy    OverflowPolicy
+	dropLevel zapcore.Level
+	done      chan struct{}
+
+	closeMu sync.RWMutex
+	closed  bool
+
+	mu        sync.Mutex
+	cond      *sync.Cond
+	enqueued  uint64
+	processed uint64
+
+	written uint64
+	dropped uint64
+	errors  uint64
+}
+
+func newAsyncWriter(cfg AsyncConfig) *asyncWriter {
+	size := cfg.QueueSize
+	if size <= 0 {
+		size = DefaultAsyncQueueSize
+	}
+	w := &asyncWriter{
+		queue:     make(chan asyncEntry, size),
+		policy:    cfg.Overflow,
+		dropLevel: cfg.DropLevel,
+		done:      make(chan struct{}),
+	}
+	w.cond = sync.NewCond(&w.mu)
+	go w.run()
+	return w
+}
+
+func (w *asyncWriter) run() {
+	defer close(w.done)
+	for e := range w.queue {
+		if err := e.write(); err != nil {
+			atomic.AddUint64(&w.errors, 1)
+		}
+		atomic.AddUint64(&w.written, 1)
+		w.markProcessed()
+	}
+}
+
+// write goes through Check of the wrapped core, so cores it tees to only get
+// the entries their own levels allow.
+func (e asyncEntry) write() error {
+	return writeChecked(e.core.Check(e.ent, nil), e.fields)
+}
+
+func (w *asyncWriter) markEnqueued() {
+	w.mu.Lock()
+	w.enqueued++
+	w.mu.Unlock()
+}
+
+func (w *asyncWriter) markProcessed() {
+	w.mu.Lock()
+	w.processed++
+	w.cond.Broadcast()
+	w.mu.Unlock()
+}
+
+func (w *asyncWriter) drop() {
+	atomic.AddUint64(&w.dropped, 1)
+	w.markProcessed()
+}
+
+func (w *asyncWriter) enqueue(e asyncEntry) error {
+	w.closeMu.RLock()
+	defer w.closeMu.RUnlock()
+	if w.closed {
+		return ErrAsyncCoreClosed
+	}
+
+	w.markEnqueued()
+	select {
+	case w.queue <- e:
+		return nil
+	default:
+	}
+
+	switch w.policy {
+	case OverflowDropNewest:
+		w.drop()
+	case OverflowDropOldest:
+		for {
+			select {
+			case w.queue <- e:
+				return nil
+			case <-w.queue:
+				w.drop()
+			}
+		}
+	case OverflowDropBelowLevel:
+		if e.ent.Level < w.dropLevel {
+			w.drop()
+			return nil
+		}
+		w.queue <- e
+	default:
+		w.queue <- e
+	}
+	return nil
+}
+
+// flush waits until every entry enqueued before the call has been written or dropped.
+func (w *asyncWriter) flush() {
+	w.mu.Lock()
+	target := w.enqueued
+	for w.processed < target {
+		w.cond.Wait()
+	}
+	w.mu.Unlock()
+}
+
+func (w *asyncWriter) close() {
+	w.closeMu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.closeMu.Unlock()
+	<-w.done
+}
+
+// AsyncCore hands entries to a bounded queue that is drained by a background
+// goroutine, so the caller never waits on the underlying sink unless the
+// overflow policy says so. It should wrap the core that does the actual
+// encoding and writing.
+type AsyncCore struct {
+	core zapcore.Core
+	w    *asyncWriter
+}
+
+var _ zapcore.Core = (*AsyncCore)(nil)
+
+func NewAsyncCore(core zapcore.Core, cfg AsyncConfig) *AsyncCore {
+	return &AsyncCore{
+		core: core,
+		w:    newAsyncWriter(cfg),
+	}
+}
+
+func (a *AsyncCore) Enabled(lvl zapcore.Level) bool {
+	return a.core.Enabled(lvl)
+}
+
+func (a *AsyncCore) With(fields []zapcore.Field) zapcore.Core {
+	return &AsyncCore{
+		core: a.core.With(fields),
+		w:    a.w,
+	}
+}
+
+func (a *AsyncCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if a.Enabled(ent.Level) {
+		return ce.AddCore(ent, a)
+	}
+	return ce
+}
+
+// Write queues the entry. Entries above error level are written before
+// Write returns, along with those queued before them, since the logger may
+// panic or exit right after.
+func (a *AsyncCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	err := a.w.enqueue(asyncEntry{
+		core:   a.core,
+		ent:    ent,
+		fields: append([]zapcore.Field(nil), fields...),
+	})
+	if err != nil || ent.Level <= zapcore.ErrorLevel {
+		return err
+	}
+	return a.Sync()
+}
+
+// Sync drains the queue and then syncs the underlying core.
+func (a *AsyncCore) Sync() error {
+	a.w.flush()
+	return a.core.Sync()
+}
+
+// Close stops accepting entries, writes everything still queued, stops the
+// background goroutine and syncs the underlying core. It is shared by all
+// cores derived from the same AsyncCore.
+func (a *AsyncCore) Close() error {
+	a.w.close()
+	return a.core.Sync()
+}
+
+func (a *AsyncCore) Stats() AsyncStats {
+	return AsyncStats{
+		Written: atomic.LoadUint64(&a.w.written),
+		Dropped: atomic.LoadUint64(&a.w.dropped),
+		Errors:  atomic.LoadUint64(&a.w.errors),
+	}
+}
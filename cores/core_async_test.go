@@ -0,0 +1,171 @@
+package cores
+
+import (
+	"encoding/json"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+type recordingCore struct {
+	mu      sync.Mutex
+	block   chan struct{}
+	entries []zapcore.Entry
+	fields  []zapcore.Field
+	synced  int
+}
+
+func (r *recordingCore) Enabled(lvl zapcore.Level) bool {
+	return lvl >= zapcore.InfoLevel
+}
+
+func (r *recordingCore) With(fields []zapcore.Field) zapcore.Core {
+	return r
+}
+
+func (r *recordingCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return ce.AddCore(ent, r)
+}
+
+func (r *recordingCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if r.block != nil {
+		<-r.block
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, ent)
+	r.fields = append(r.fields, fields...)
+	return nil
+}
+
+func (r *recordingCore) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.synced++
+	return nil
+}
+
+func (r *recordingCore) messages() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	msgs := make([]string, 0, len(r.entries))
+	for _, ent := range r.entries {
+		msgs = append(msgs, ent.Message)
+	}
+	return msgs
+}
+
+func TestAsyncCore_Write(t *testing.T) {
+	core := &recordingCore{}
+	asyncCore := NewAsyncCore(core, AsyncConfig{})
+	defer asyncCore.Close()
+
+	field := zapcore.Field{Key: "key", Type: zapcore.StringType, String: "value"}
+	for _, msg := range []string{"a", "b", "c"} {
+		ce := asyncCore.Check(zapcore.Entry{Level: zapcore.InfoLevel, Message: msg}, nil)
+		assert.NotNil(t, ce, "Expected Check to add the core for enabled levels")
+		ce.Write(field)
+	}
+	assert.Nil(t, asyncCore.Check(zapcore.Entry{Level: zapcore.DebugLevel}, nil), "Expected Check to skip disabled levels")
+
+	assert.NoError(t, asyncCore.Sync(), "Expected Sync to not return an error")
+	assert.Equal(t, []string{"a", "b", "c"}, core.messages(), "Expected entries to be written in order")
+	assert.Len(t, core.fields, 3, "Expected fields to be written")
+	assert.Equal(t, 1, core.synced, "Expected Sync to reach the underlying core")
+	assert.Equal(t, AsyncStats{Written: 3}, asyncCore.Stats())
+}
+
+func TestAsyncCore_With(t *testing.T) {
+	core := &recordingCore{}
+	asyncCore := NewAsyncCore(core, AsyncConfig{})
+	defer asyncCore.Close()
+
+	child := asyncCore.With(nil).(*AsyncCore)
+	assert.Equal(t, asyncCore.w, child.w, "Expected derived cores to share the queue")
+
+	assert.NoError(t, child.Write(zapcore.Entry{Message: "child"}, nil))
+	assert.NoError(t, asyncCore.Sync())
+	assert.Equal(t, []string{"child"}, core.messages())
+}
+
+func TestAsyncCore_Overflow(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         AsyncConfig
+		levels      []zapcore.Level
+		wantDropped uint64
+		want        []string
+	}{
+		{
+			name:        "drop newest",
+			cfg:         AsyncConfig{QueueSize: 1, Overflow: OverflowDropNewest},
+			levels:      []zapcore.Level{zapcore.InfoLevel, zapcore.InfoLevel, zapcore.InfoLevel},
+			wantDropped: 1,
+			want:        []string{"0", "1"},
+		},
+		{
+			name:        "drop oldest",
+			cfg:         AsyncConfig{QueueSize: 1, Overflow: OverflowDropOldest},
+			levels:      []zapcore.Level{zapcore.InfoLevel, zapcore.InfoLevel, zapcore.InfoLevel},
+			wantDropped: 1,
+			want:        []string{"0", "2"},
+		},
+		{
+			name:        "drop below level",
+			cfg:         AsyncConfig{QueueSize: 1, Overflow: OverflowDropBelowLevel, DropLevel: zapcore.ErrorLevel},
+			levels:      []zapcore.Level{zapcore.InfoLevel, zapcore.InfoLevel, zapcore.WarnLevel},
+			wantDropped: 1,
+			want:        []string{"0", "1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core := &recordingCore{block: make(chan struct{})}
+			asyncCore := NewAsyncCore(core, tt.cfg)
+
+			// The first entry is picked up by the writer goroutine and blocks there,
+			// wait until it has left the queue before filling it.
+			assert.NoError(t, asyncCore.Write(zapcore.Entry{Level: tt.levels[0], Message: "0"}, nil))
+			for len(asyncCore.w.queue) != 0 {
+				runtime.Gosched()
+			}
+			for i, lvl := range tt.levels[1:] {
+				msg := string(rune('1' + i))
+				assert.NoError(t, asyncCore.Write(zapcore.Entry{Level: lvl, Message: msg}, nil))
+			}
+
+			close(core.block)
+			assert.NoError(t, asyncCore.Close())
+			assert.Equal(t, tt.want, core.messages())
+			assert.Equal(t, tt.wantDropped, asyncCore.Stats().Dropped)
+		})
+	}
+}
+
+func TestAsyncCore_Close(t *testing.T) {
+	core := &recordingCore{}
+	asyncCore := NewAsyncCore(core, AsyncConfig{})
+
+	assert.NoError(t, asyncCore.Write(zapcore.Entry{Message: "before"}, nil))
+	assert.NoError(t, asyncCore.Close(), "Expected Close to not return an error")
+	assert.NoError(t, asyncCore.Close(), "Expected Close to be idempotent")
+	assert.Equal(t, []string{"before"}, core.messages(), "Expected queued entries to be written on Close")
+
+	err := asyncCore.Write(zapcore.Entry{Message: "after"}, nil)
+	assert.ErrorIs(t, err, ErrAsyncCoreClosed)
+	assert.NoError(t, asyncCore.Sync(), "Expected Sync after Close to not block")
+}
+
+func TestOverflowPolicy_UnmarshalText(t *testing.T) {
+	var cfg AsyncConfig
+	err := json.Unmarshal([]byte(`{"queueSize":8,"overflow":"dropBelowLevel","dropLevel":"warn"}`), &cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, AsyncConfig{QueueSize: 8, Overflow: OverflowDropBelowLevel, DropLevel: zapcore.WarnLevel}, cfg)
+
+	err = json.Unmarshal([]byte(`{"overflow":"unknown"}`), &cfg)
+	assert.Error(t, err, "Expected error for unknown overflow policy")
+	assert.Equal(t, "dropOldest", OverflowDropOldest.String())
+}
@@ -0,0 +1,302 @@
+package cores
+
+import (
+	"container/list"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	DefaultDedupWindow  = 10 * time.Second
+	DefaultDedupMaxKeys = 1000
+	DefaultDedupKey     = "repeated"
+)
+
+type DedupConfig struct {
+	// Window is how long duplicates of an entry are collapsed, DefaultDedupWindow if zero.
+	Window time.Duration `json:"window" yaml:"window"`
+	// KeyFields are the field keys whose values are part of the identity of an
+	// entry, in addition to its message, level and caller.
+	KeyFields []string `json:"keyFields" yaml:"keyFields"`
+	// MaxKeys bounds the number of tracked entries, DefaultDedupMaxKeys if zero.
+	// The least recently seen entry is evicted first.
+	MaxKeys int `json:"maxKeys" yaml:"maxKeys"`
+	// CountKey is the key of the field holding the number of repetitions.
+	CountKey string `json:"countKey" yaml:"countKey"`
+}
+
+type dedupEntry struct {
+	key     string
+	core    zapcore.Core
+	ent     zapcore.Entry
+	fields  []zapcore.Field
+	expires time.Time
+	count   int
+}
+
+// deduper holds the tracked entries shared by a DedupCore and the cores
+// derived from it with With.
+type deduper struct {
+	window  time.Duration
+	maxKeys int
+
+	mu        sync.Mutex
+	lru       *list.List // of *dedupEntry, most recently seen first
+	entries   map[string]*list.Element
+	nextSweep time.Time
+
+	sweeps *ticker
+}
+
+// seen records an occurrence of key and reports whether it is the first one
+// in its window and whether it is its first repetition. It returns the entries whose window closed or that were
+// evicted and still have repetitions to report.
+func (d *deduper) seen(e *dedupEntry, now time.Time) (first, repeated bool, closed []*dedupEntry) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	closed = d.sweep(now, false)
+	if el, ok := d.entries[e.key]; ok {
+		d.lru.MoveToFront(el)
+		tracked := el.Value.(*dedupEntry)
+		tracked.count++
+		return false, tracked.count == 1, closed
+	}
+
+	if d.lru.Len() >= d.maxKeys {
+		if el := d.lru.Back(); el != nil {
+			old := d.remove(el)
+			if old.count > 0 {
+				closed = append(closed, old)
+			}
+		}
+	}
+	e.expires = now.Add(d.window)
+	d.entries[e.key] = d.lru.PushFront(e)
+	if d.nextSweep.IsZero() || e.expires.Before(d.nextSweep) {
+		d.nextSweep = e.expires
+	}
+	return true, false, closed
+}
+
+// sweep removes the entries whose window closed, or all entries if force is
+// set, and returns those with repetitions to report in the order they were
+// first seen.
+func (d *deduper) sweep(now time.Time, force bool) []*dedupEntry {
+	if !force && (d.nextSweep.IsZero() || now.Before(d.nextSweep)) {
+		return nil
+	}
+
+	var closed []*dedupEntry
+	d.nextSweep = time.Time{}
+	for el := d.lru.Back(); el != nil; {
+		prev := el.Prev()
+		e := el.Value.(*dedupEntry)
+		if force || !now.Before(e.expires) {
+			d.remove(el)
+			if e.count > 0 {
+				closed = append(closed, e)
+			}
+		} else if d.nextSweep.IsZero() || e.expires.Before(d.nextSweep) {
+			d.nextSweep = e.expires
+		}
+		el = prev
+	}
+	sort.Slice(closed, func(i, j int) bool {
+		return closed[i].expires.Before(closed[j].expires)
+	})
+	return closed
+}
+
+func (d *deduper) remove(el *list.Element) *dedupEntry {
+	e := d.lru.Remove(el).(*dedupEntry)
+	delete(d.entries, e.key)
+	return e
+}
+
+// DedupCore writes the first occurrence of an entry and collapses identical
+// entries that follow within the window into a single entry reporting the
+// number of repetitions. Closed windows are swept every Window by a background
+// goroutine, started once entries repeat and stopped by Close, as well as on
+// the next write and on Sync.
+type DedupCore struct {
+	core zapcore.Core
+	d    *deduper
+
+	keyFields  []string
+	countKey   string
+	withFields []zapcore.Field
+
+	// root is the core created by NewDedupCore, which sweeps the closed
+	// windows in the background.
+	root *DedupCore
+	now  func() time.Time
+}
+
+var _ zapcore.Core = (*DedupCore)(nil)
+
+func NewDedupCore(core zapcore.Core, cfg DedupConfig) *DedupCore {
+	d := &deduper{
+		window:  cfg.Window,
+		maxKeys: cfg.MaxKeys,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+		sweeps:  newTicker(),
+	}
+	if d.window <= 0 {
+		d.window = DefaultDedupWindow
+	}
+	if d.maxKeys <= 0 {
+		d.maxKeys = DefaultDedupMaxKeys
+	}
+
+	c := &DedupCore{
+		core:      core,
+		d:         d,
+		keyFields: cfg.KeyFields,
+		countKey:  cfg.CountKey,
+		now:       time.Now,
+	}
+	c.root = c
+	if c.countKey == "" {
+		c.countKey = DefaultDedupKey
+	}
+	return c
+}
+
+func (c *DedupCore) Enabled(lvl zapcore.Level) bool {
+	return c.core.Enabled(lvl)
+}
+
+func (c *DedupCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.core = c.core.With(fields)
+	if len(c.keyFields) > 0 {
+		clone.withFields = append(c.withFields[:len(c.withFields):len(c.withFields)], fields...)
+	}
+	return &clone
+}
+
+func (c *DedupCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write writes the first occurrence of the entry in its window and counts the
+// others.
+func (c *DedupCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	key, keyFields := c.identity(ent, fields)
+	first, repeated, closed := c.d.seen(&dedupEntry{key: key, core: c.core, ent: ent, fields: keyFields}, c.now())
+	if repeated {
+		c.d.sweeps.start(c.d.window, c.root.sweep)
+	}
+
+	err := c.writeRepeated(closed)
+	if !first {
+		return err
+	}
+	if werr := writeChecked(c.core.Check(ent, nil), fields); werr != nil {
+		err = werr
+	}
+	return err
+}
+
+// Sync writes the repetitions of every tracked entry and syncs the wrapped core.
+func (c *DedupCore) Sync() error {
+	c.d.mu.Lock()
+	closed := c.d.sweep(c.now(), true)
+	c.d.mu.Unlock()
+
+	err := c.writeRepeated(closed)
+	if serr := c.core.Sync(); serr != nil {
+		err = serr
+	}
+	return err
+}
+
+// Close stops the goroutine sweeping the closed windows and writes the
+// repetitions of every tracked entry. It is shared by all cores derived from
+// the same DedupCore.
+func (c *DedupCore) Close() error {
+	c.d.sweeps.stop()
+	return c.Sync()
+}
+
+func (c *DedupCore) sweep() {
+	c.d.mu.Lock()
+	closed := c.d.sweep(c.now(), false)
+	c.d.mu.Unlock()
+
+	_ = c.writeRepeated(closed)
+}
+
+// identity returns the key of the entry and the fields that are part of it.
+func (c *DedupCore) identity(ent zapcore.Entry, fields []zapcore.Field) (string, []zapcore.Field) {
+	var b strings.Builder
+	b.WriteString(ent.Level.String())
+	b.WriteByte(0)
+	b.WriteString(ent.LoggerName)
+	b.WriteByte(0)
+	if ent.Caller.Defined {
+		b.WriteString(ent.Caller.File)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(ent.Caller.Line))
+	}
+	b.WriteByte(0)
+	b.WriteString(ent.Message)
+
+	var keyFields []zapcore.Field
+	for _, k := range c.keyFields {
+		f, ok := lastField(fields, k)
+		fromEntry := ok
+		if !ok {
+			f, ok = lastField(c.withFields, k)
+		}
+		b.WriteByte(0)
+		if !ok {
+			continue
+		}
+		v, _ := keyFieldValue([]zapcore.Field{f}, k)
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(v)
+		// Fields added with With are already part of the core.
+		if fromEntry {
+			keyFields = append(keyFields, f)
+		}
+	}
+	return b.String(), keyFields
+}
+
+func lastField(fields []zapcore.Field, key string) (zapcore.Field, bool) {
+	for i := len(fields) - 1; i >= 0; i-- {
+		if fields[i].Key == key {
+			return fields[i], true
+		}
+	}
+	return zapcore.Field{}, false
+}
+
+func (c *DedupCore) writeRepeated(entries []*dedupEntry) error {
+	var err error
+	for _, e := range entries {
+		ent := e.ent
+		ent.Time = c.now()
+		ent.Stack = ""
+		ent.Message = fmt.Sprintf("%s (repeated %d times)", e.ent.Message, e.count)
+
+		fields := append(e.fields, zapcore.Field{Key: c.countKey, Type: zapcore.Int64Type, Integer: int64(e.count)})
+		if werr := writeChecked(e.core.Check(ent, nil), fields); werr != nil {
+			err = werr
+		}
+	}
+	return err
+}
@@ -0,0 +1,137 @@
+package cores
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func newDedupTestCore(cfg DedupConfig) (*DedupCore, *bytes.Buffer, *manualClock) {
+	inner, buf := newBufferCore(zapcore.DebugLevel)
+	core, clock := NewDedupCore(inner, cfg), newManualClock()
+	core.now = clock.now
+	return core, buf, clock
+}
+
+func TestDedupCore_window(t *testing.T) {
+	core, buf, clock := newDedupTestCore(DedupConfig{Window: time.Second})
+
+	for i := 0; i < 4; i++ {
+		logAt(core, zapcore.ErrorLevel, "db down", zap.Int("attempt", i))
+	}
+	logAt(core, zapcore.WarnLevel, "db down")
+	assert.Equal(t, []string{
+		`{"level":"error","msg":"db down","attempt":0}`,
+		`{"level":"warn","msg":"db down"}`,
+	}, lines(buf), "Expected only first occurrences per level")
+
+	clock.add(time.Second)
+	logAt(core, zapcore.ErrorLevel, "db down", zap.Int("attempt", 4))
+	assert.Equal(t, []string{
+		`{"level":"error","msg":"db down (repeated 3 times)","repeated":3}`,
+		`{"level":"error","msg":"db down","attempt":4}`,
+	}, lines(buf), "Expected the repetitions once the window closed, then a new first occurrence")
+
+	logAt(core, zapcore.ErrorLevel, "db down")
+	assert.Empty(t, lines(buf), "Expected the duplicate to be collapsed")
+	assert.NoError(t, core.Sync())
+	assert.Equal(t, []string{`{"level":"error","msg":"db down (repeated 1 times)","repeated":1}`}, lines(buf), "Expected Sync to report pending repetitions")
+
+	assert.NoError(t, core.Sync())
+	logAt(core, zapcore.ErrorLevel, "db down")
+	assert.Len(t, lines(buf), 1, "Expected a new first occurrence after Sync")
+}
+
+func TestDedupCore_sweepTicker(t *testing.T) {
+	core, buf, clock := newDedupTestCore(DedupConfig{Window: time.Second})
+	ticks := manualTicker(core.d.sweeps)
+
+	logAt(core, zapcore.InfoLevel, "m")
+	logAt(core, zapcore.InfoLevel, "m")
+	logAt(core.With([]zapcore.Field{zap.String("req", "1")}), zapcore.InfoLevel, "m")
+	assert.Len(t, lines(buf), 1)
+
+	clock.add(time.Second)
+	ticks <- clock.now()
+	// A second tick is only received once the first sweep is written.
+	ticks <- clock.now()
+	assert.Equal(t, []string{
+		`{"level":"info","msg":"m (repeated 2 times)","repeated":2}`,
+	}, lines(buf), "Expected the repetitions without a later write")
+
+	logAt(core, zapcore.InfoLevel, "m")
+	logAt(core, zapcore.InfoLevel, "m")
+	lines(buf)
+	assert.NoError(t, core.Close())
+	assert.Equal(t, []string{
+		`{"level":"info","msg":"m (repeated 1 times)","repeated":1}`,
+	}, lines(buf), "Expected Close to write pending repetitions")
+	select {
+	case ticks <- clock.now():
+		t.Error("Expected Close to stop the ticker")
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestDedupCore_caller(t *testing.T) {
+	core, buf, _ := newDedupTestCore(DedupConfig{})
+	write := func(line int) {
+		ent := zapcore.Entry{Level: zapcore.InfoLevel, Message: "m", Caller: zapcore.NewEntryCaller(0, "/src/app/main.go", line, true)}
+		if ce := core.Check(ent, nil); ce != nil {
+			ce.Write()
+		}
+	}
+	write(10)
+	write(10)
+	write(20)
+	assert.Len(t, lines(buf), 2, "Expected different callers to be different entries")
+}
+
+func TestDedupCore_keyFields(t *testing.T) {
+	core, buf, _ := newDedupTestCore(DedupConfig{KeyFields: []string{"host"}})
+
+	logAt(core, zapcore.ErrorLevel, "down", zap.String("host", "a"), zap.Int("n", 1))
+	logAt(core, zapcore.ErrorLevel, "down", zap.String("host", "a"), zap.Int("n", 2))
+	logAt(core, zapcore.ErrorLevel, "down", zap.String("host", "b"))
+	child := core.With([]zapcore.Field{zap.String("host", "c")})
+	logAt(child, zapcore.ErrorLevel, "down")
+	logAt(child, zapcore.ErrorLevel, "down")
+	assert.Len(t, lines(buf), 3, "Expected one entry per host")
+
+	assert.NoError(t, core.Sync())
+	assert.Equal(t, []string{
+		`{"level":"error","msg":"down (repeated 1 times)","host":"a","repeated":1}`,
+		`{"level":"error","msg":"down (repeated 1 times)","host":"c","repeated":1}`,
+	}, lines(buf), "Expected the key fields in the repetition entries")
+}
+
+func TestDedupCore_lru(t *testing.T) {
+	core, buf, clock := newDedupTestCore(DedupConfig{MaxKeys: 2, CountKey: "count"})
+
+	logAt(core, zapcore.InfoLevel, "a")
+	logAt(core, zapcore.InfoLevel, "a")
+	clock.add(time.Millisecond)
+	logAt(core, zapcore.InfoLevel, "b")
+	logAt(core, zapcore.InfoLevel, "a")
+	assert.Equal(t, []string{
+		`{"level":"info","msg":"a"}`,
+		`{"level":"info","msg":"b"}`,
+	}, lines(buf))
+
+	logAt(core, zapcore.InfoLevel, "c")
+	assert.Equal(t, []string{`{"level":"info","msg":"c"}`}, lines(buf), "Expected the least recently seen entry to be evicted silently")
+	assert.Equal(t, 2, core.d.lru.Len(), "Expected the number of entries to be bounded")
+
+	logAt(core, zapcore.InfoLevel, "d")
+	assert.Equal(t, []string{
+		`{"level":"info","msg":"a (repeated 2 times)","count":2}`,
+		`{"level":"info","msg":"d"}`,
+	}, lines(buf), "Expected evicted entries to report their repetitions")
+
+	logAt(core, zapcore.InfoLevel, "b")
+	assert.Equal(t, []string{`{"level":"info","msg":"b"}`}, lines(buf), "Expected evicted entries to start over")
+}
@@ -0,0 +1,338 @@
+package cores
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	DefaultRateLimitTick    = time.Second
+	DefaultRateLimitBurst   = 100
+	DefaultRateLimitMaxKeys = 10000
+	DefaultRateLimitMessage = "log entries suppressed by rate limit"
+)
+
+type RateLimitConfig struct {
+	// Tick is the refill period, DefaultRateLimitTick if zero.
+	Tick time.Duration `json:"tick" yaml:"tick"`
+	// Burst is the size of the bucket of every key, DefaultRateLimitBurst if zero.
+	Burst int `json:"burst" yaml:"burst"`
+	// Refill is the number of tokens added every Tick, Burst if zero.
+	Refill int `json:"refill" yaml:"refill"`
+	// KeyField keys the buckets by the value of the field, by level and
+	// message for entries without it.
+	KeyField string `json:"keyField" yaml:"keyField"`
+	// KeyByCaller keys the buckets by the caller location instead of the message.
+	KeyByCaller bool `json:"keyByCaller" yaml:"keyByCaller"`
+	// MaxKeys bounds the number of tracked keys, DefaultRateLimitMaxKeys if zero.
+	MaxKeys int `json:"maxKeys" yaml:"maxKeys"`
+	// SummaryInterval is how often the suppressed counts are reported, Tick if zero.
+	SummaryInterval time.Duration `json:"summaryInterval" yaml:"summaryInterval"`
+	// SummaryMessage is the message of the summary entries.
+	SummaryMessage string `json:"summaryMessage" yaml:"summaryMessage"`
+}
+
+type rateLimitKey struct {
+	level zapcore.Level
+	key   string
+}
+
+type bucket struct {
+	tokens     float64
+	last       time.Time
+	suppressed uint64
+}
+
+// rateLimiter holds the buckets shared by a RateLimitCore and the cores
+// derived from it with With.
+type rateLimiter struct {
+	tick    time.Duration
+	burst   float64
+	refill  float64
+	maxKeys int
+
+	mu          sync.Mutex
+	buckets     map[rateLimitKey]*bucket
+	lastSummary time.Time
+	// evicted holds the suppressed counts of buckets dropped by evict until
+	// the next summary.
+	evicted map[rateLimitKey]uint64
+
+	summaries *ticker
+}
+
+func (l *rateLimiter) allow(k rateLimitKey, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[k]
+	if !ok {
+		if len(l.buckets) >= l.maxKeys {
+			l.evict(now)
+		}
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[k] = b
+	}
+
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += float64(elapsed) / float64(l.tick) * l.refill
+		if b.tokens > l.burst {
+			b.tokens = l.burst
+		}
+		b.last = now
+	}
+	if b.tokens >= 1 {
+		b.tokens--
+		return true
+	}
+	b.suppressed++
+	return false
+}
+
+// evict removes buckets that are refilled and have nothing to report, which
+// behave like new ones. If none are, the whole map is dropped, keeping the
+// suppressed counts for the next summary.
+func (l *rateLimiter) evict(now time.Time) {
+	for k, b := range l.buckets {
+		full := b.tokens+float64(now.Sub(b.last))/float64(l.tick)*l.refill >= l.burst
+		if full && b.suppressed == 0 {
+			delete(l.buckets, k)
+		}
+	}
+	if len(l.buckets) < l.maxKeys {
+		return
+	}
+	for k, b := range l.buckets {
+		if b.suppressed > 0 {
+			if l.evicted == nil {
+				l.evicted = make(map[rateLimitKey]uint64)
+			}
+			l.evicted[k] += b.suppressed
+		}
+	}
+	l.buckets = make(map[rateLimitKey]*bucket, l.maxKeys)
+}
+
+type suppressedCount struct {
+	key   rateLimitKey
+	count uint64
+}
+
+// takeSummary returns and resets the suppressed counts, at most once per interval
+// unless force is set.
+func (l *rateLimiter) takeSummary(now time.Time, interval time.Duration, force bool) []suppressedCount {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.lastSummary.IsZero() {
+		l.lastSummary = now
+	}
+	if !force && now.Sub(l.lastSummary) < interval {
+		return nil
+	}
+	l.lastSummary = now
+
+	pending := l.evicted
+	l.evicted = nil
+	for k, b := range l.buckets {
+		if b.suppressed > 0 {
+			if pending == nil {
+				pending = make(map[rateLimitKey]uint64)
+			}
+			pending[k] += b.suppressed
+			b.suppressed = 0
+		}
+	}
+	counts := make([]suppressedCount, 0, len(pending))
+	for k, n := range pending {
+		counts = append(counts, suppressedCount{key: k, count: n})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].key.level != counts[j].key.level {
+			return counts[i].key.level < counts[j].key.level
+		}
+		return counts[i].key.key < counts[j].key.key
+	})
+	return counts
+}
+
+// RateLimitCore drops entries once the token bucket of their key is empty.
+// Every key starts with Burst tokens and gets Refill tokens back every Tick.
+// The number of dropped entries per key is written as a summary entry at warn
+// level every SummaryInterval by a background goroutine, started once entries
+// are dropped and stopped by Close, as well as on Sync.
+type RateLimitCore struct {
+	core    zapcore.Core
+	limiter *rateLimiter
+
+	keyField    string
+	keyByCaller bool
+	interval    time.Duration
+	message     string
+	withKey     string
+	hasWithKey  bool
+
+	// root is the core created by NewRateLimitCore, which writes the periodic
+	// summaries without the fields of derived cores.
+	root *RateLimitCore
+	now  func() time.Time
+}
+
+var _ zapcore.Core = (*RateLimitCore)(nil)
+
+func NewRateLimitCore(core zapcore.Core, cfg RateLimitConfig) *RateLimitCore {
+	l := &rateLimiter{
+		tick:      cfg.Tick,
+		burst:     float64(cfg.Burst),
+		refill:    float64(cfg.Refill),
+		maxKeys:   cfg.MaxKeys,
+		buckets:   make(map[rateLimitKey]*bucket),
+		summaries: newTicker(),
+	}
+	if l.tick <= 0 {
+		l.tick = DefaultRateLimitTick
+	}
+	if l.burst <= 0 {
+		l.burst = DefaultRateLimitBurst
+	}
+	if l.refill <= 0 {
+		l.refill = l.burst
+	}
+	if l.maxKeys <= 0 {
+		l.maxKeys = DefaultRateLimitMaxKeys
+	}
+
+	c := &RateLimitCore{
+		core:        core,
+		limiter:     l,
+		keyField:    cfg.KeyField,
+		keyByCaller: cfg.KeyByCaller,
+		interval:    cfg.SummaryInterval,
+		message:     cfg.SummaryMessage,
+		now:         time.Now,
+	}
+	c.root = c
+	if c.interval <= 0 {
+		c.interval = l.tick
+	}
+	if c.message == "" {
+		c.message = DefaultRateLimitMessage
+	}
+	return c
+}
+
+func (c *RateLimitCore) Enabled(lvl zapcore.Level) bool {
+	return c.core.Enabled(lvl)
+}
+
+func (c *RateLimitCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.core = c.core.With(fields)
+	if c.keyField != "" {
+		if v, ok := keyFieldValue(fields, c.keyField); ok {
+			clone.withKey, clone.hasWithKey = v, true
+		}
+	}
+	return &clone
+}
+
+func (c *RateLimitCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write decides here rather than in Check, since the caller and the fields
+// of the entry are only known once it is written.
+func (c *RateLimitCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	now := c.now()
+	err := c.writeSummary(now, false)
+
+	if !c.limiter.allow(c.key(ent, fields), now) {
+		c.limiter.summaries.start(c.interval, c.root.summarize)
+		return err
+	}
+	if werr := writeChecked(c.core.Check(ent, nil), fields); werr != nil {
+		err = werr
+	}
+	return err
+}
+
+// Sync writes the pending summary and syncs the wrapped core.
+func (c *RateLimitCore) Sync() error {
+	err := c.writeSummary(c.now(), true)
+	if serr := c.core.Sync(); serr != nil {
+		err = serr
+	}
+	return err
+}
+
+// Close stops the goroutine writing the summaries and writes the pending one.
+// It is shared by all cores derived from the same RateLimitCore.
+func (c *RateLimitCore) Close() error {
+	c.limiter.summaries.stop()
+	return c.Sync()
+}
+
+func (c *RateLimitCore) summarize() {
+	_ = c.writeSummary(c.now(), false)
+}
+
+func (c *RateLimitCore) key(ent zapcore.Entry, fields []zapcore.Field) rateLimitKey {
+	if c.keyField != "" {
+		if v, ok := keyFieldValue(fields, c.keyField); ok {
+			return rateLimitKey{level: ent.Level, key: c.keyField + "=" + v}
+		}
+		if c.hasWithKey {
+			return rateLimitKey{level: ent.Level, key: c.keyField + "=" + c.withKey}
+		}
+	}
+	if c.keyByCaller && ent.Caller.Defined {
+		return rateLimitKey{level: ent.Level, key: ent.Caller.TrimmedPath()}
+	}
+	return rateLimitKey{level: ent.Level, key: ent.Message}
+}
+
+func (c *RateLimitCore) writeSummary(now time.Time, force bool) error {
+	counts := c.limiter.takeSummary(now, c.interval, force)
+	var err error
+	for _, sc := range counts {
+		ent := zapcore.Entry{Level: zapcore.WarnLevel, Time: now, Message: c.message}
+		fields := []zapcore.Field{
+			{Key: "rate_limit_key", Type: zapcore.StringType, String: sc.key.key},
+			{Key: "rate_limit_level", Type: zapcore.StringType, String: sc.key.level.String()},
+			{Key: "suppressed", Type: zapcore.Uint64Type, Integer: int64(sc.count)},
+		}
+		if werr := writeChecked(c.core.Check(ent, nil), fields); werr != nil {
+			err = werr
+		}
+	}
+	return err
+}
+
+// keyFieldValue returns the string form of the last field named key.
+func keyFieldValue(fields []zapcore.Field, key string) (string, bool) {
+	for i := len(fields) - 1; i >= 0; i-- {
+		f := fields[i]
+		if f.Key != key {
+			continue
+		}
+		switch f.Type {
+		case zapcore.StringType:
+			return f.String, true
+		case zapcore.Int64Type, zapcore.Int32Type, zapcore.Int16Type, zapcore.Int8Type:
+			return strconv.FormatInt(f.Integer, 10), true
+		case zapcore.Uint64Type, zapcore.Uint32Type, zapcore.Uint16Type, zapcore.Uint8Type, zapcore.UintptrType:
+			return strconv.FormatUint(uint64(f.Integer), 10), true
+		default:
+			return fmt.Sprint(fieldValue(f)), true
+		}
+	}
+	return "", false
+}
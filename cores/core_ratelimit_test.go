@@ -0,0 +1,176 @@
+package cores
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type manualClock struct {
+	t time.Time
+}
+
+func (c *manualClock) now() time.Time {
+	return c.t
+}
+
+func (c *manualClock) add(d time.Duration) {
+	c.t = c.t.Add(d)
+}
+
+func newManualClock() *manualClock {
+	return &manualClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func newRateLimitTestCore(cfg RateLimitConfig) (*RateLimitCore, *bytes.Buffer, *manualClock) {
+	inner, buf := newBufferCore(zapcore.DebugLevel)
+	core, clock := NewRateLimitCore(inner, cfg), newManualClock()
+	core.now = clock.now
+	return core, buf, clock
+}
+
+func TestRateLimitCore_tokenBucket(t *testing.T) {
+	core, buf, clock := newRateLimitTestCore(RateLimitConfig{Tick: time.Second, Burst: 3, Refill: 1, SummaryInterval: time.Hour})
+
+	for i := 0; i < 5; i++ {
+		logAt(core, zapcore.InfoLevel, "m")
+	}
+	assert.Len(t, lines(buf), 3, "Expected the burst to pass")
+
+	clock.add(time.Second)
+	logAt(core, zapcore.InfoLevel, "m")
+	logAt(core, zapcore.InfoLevel, "m")
+	assert.Len(t, lines(buf), 1, "Expected one token after one tick")
+
+	clock.add(10 * time.Second)
+	for i := 0; i < 5; i++ {
+		logAt(core, zapcore.InfoLevel, "m")
+	}
+	assert.Len(t, lines(buf), 3, "Expected refill to stop at the burst")
+
+	logAt(core, zapcore.InfoLevel, "other")
+	logAt(core, zapcore.WarnLevel, "m")
+	assert.Len(t, lines(buf), 2, "Expected other messages and levels to have their own buckets")
+}
+
+func TestRateLimitCore_keyField(t *testing.T) {
+	core, buf, _ := newRateLimitTestCore(RateLimitConfig{Burst: 1, KeyField: "user_id", SummaryInterval: time.Hour})
+
+	logAt(core, zapcore.InfoLevel, "a", zap.Int("user_id", 1))
+	logAt(core, zapcore.InfoLevel, "b", zap.Int("user_id", 1))
+	logAt(core, zapcore.InfoLevel, "a", zap.Int("user_id", 2))
+	assert.Len(t, lines(buf), 2, "Expected one entry per user")
+
+	child := core.With([]zapcore.Field{zap.Int("user_id", 3)})
+	logAt(child, zapcore.InfoLevel, "a")
+	logAt(child, zapcore.InfoLevel, "b")
+	assert.Len(t, lines(buf), 1, "Expected the key to come from With fields")
+
+	errCore, errBuf, _ := newRateLimitTestCore(RateLimitConfig{Burst: 1, KeyField: "error", SummaryInterval: time.Hour})
+	logAt(errCore, zapcore.InfoLevel, "x", zap.Error(errors.New("timeout")))
+	logAt(errCore, zapcore.InfoLevel, "y", zap.Error(errors.New("timeout")))
+	logAt(errCore, zapcore.InfoLevel, "x", zap.Error(errors.New("refused")))
+	assert.Len(t, lines(errBuf), 2, "Expected one entry per error")
+}
+
+func TestRateLimitCore_keyByCaller(t *testing.T) {
+	core, buf, _ := newRateLimitTestCore(RateLimitConfig{Burst: 1, KeyByCaller: true, SummaryInterval: time.Hour})
+
+	write := func(msg string, line int) {
+		ent := zapcore.Entry{Level: zapcore.InfoLevel, Message: msg, Caller: zapcore.NewEntryCaller(0, "/src/app/main.go", line, true)}
+		if ce := core.Check(ent, nil); ce != nil {
+			ce.Write()
+		}
+	}
+	write("a", 10)
+	write("b", 10)
+	write("a", 20)
+	assert.Len(t, lines(buf), 2, "Expected one entry per caller")
+}
+
+func TestRateLimitCore_summary(t *testing.T) {
+	core, buf, clock := newRateLimitTestCore(RateLimitConfig{Burst: 1, KeyField: "user_id", SummaryInterval: time.Minute})
+
+	logAt(core, zapcore.InfoLevel, "m", zap.String("user_id", "a"))
+	logAt(core, zapcore.InfoLevel, "m", zap.String("user_id", "a"))
+	logAt(core, zapcore.InfoLevel, "m", zap.String("user_id", "a"))
+	logAt(core, zapcore.ErrorLevel, "m", zap.String("user_id", "b"))
+	logAt(core, zapcore.ErrorLevel, "m", zap.String("user_id", "b"))
+	assert.Len(t, lines(buf), 2, "Expected one entry per key")
+
+	clock.add(time.Minute)
+	logAt(core, zapcore.InfoLevel, "m", zap.String("user_id", "c"))
+	assert.Equal(t, []string{
+		`{"level":"warn","msg":"log entries suppressed by rate limit","rate_limit_key":"user_id=a","rate_limit_level":"info","suppressed":2}`,
+		`{"level":"warn","msg":"log entries suppressed by rate limit","rate_limit_key":"user_id=b","rate_limit_level":"error","suppressed":1}`,
+		`{"level":"info","msg":"m","user_id":"c"}`,
+	}, lines(buf), "Expected the summary before the next entry")
+
+	logAt(core, zapcore.InfoLevel, "m", zap.String("user_id", "c"))
+	assert.Empty(t, lines(buf), "Expected no summary within the interval")
+	assert.NoError(t, core.Sync())
+	assert.Equal(t, []string{
+		`{"level":"warn","msg":"log entries suppressed by rate limit","rate_limit_key":"user_id=c","rate_limit_level":"info","suppressed":1}`,
+	}, lines(buf), "Expected Sync to write the pending summary")
+
+	assert.NoError(t, core.Sync())
+	assert.Empty(t, lines(buf), "Expected no empty summary")
+}
+
+// manualTicker makes t tick when a time is sent on the returned channel.
+func manualTicker(t *ticker) chan time.Time {
+	ticks := make(chan time.Time)
+	t.newTicker = func(time.Duration) (<-chan time.Time, func()) {
+		return ticks, func() {}
+	}
+	return ticks
+}
+
+func TestRateLimitCore_summaryTicker(t *testing.T) {
+	core, buf, clock := newRateLimitTestCore(RateLimitConfig{Burst: 1, SummaryInterval: time.Minute})
+	ticks := manualTicker(core.limiter.summaries)
+
+	logAt(core, zapcore.InfoLevel, "m")
+	logAt(core.With([]zapcore.Field{zap.String("req", "1")}), zapcore.InfoLevel, "m")
+	assert.Len(t, lines(buf), 1)
+
+	clock.add(time.Minute)
+	ticks <- clock.now()
+	// A second tick is only received once the first summary is written.
+	ticks <- clock.now()
+	assert.Equal(t, []string{
+		`{"level":"warn","msg":"log entries suppressed by rate limit","rate_limit_key":"m","rate_limit_level":"info","suppressed":1}`,
+	}, lines(buf), "Expected the summary without a later write")
+
+	assert.NoError(t, core.Close())
+	select {
+	case ticks <- clock.now():
+		t.Error("Expected Close to stop the ticker")
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestRateLimitCore_maxKeys(t *testing.T) {
+	core, buf, clock := newRateLimitTestCore(RateLimitConfig{Burst: 1, MaxKeys: 2, KeyField: "k", SummaryInterval: time.Hour})
+
+	logAt(core, zapcore.InfoLevel, "m", zap.Int("k", 1))
+	logAt(core, zapcore.InfoLevel, "m", zap.Int("k", 2))
+	clock.add(time.Second)
+	logAt(core, zapcore.InfoLevel, "m", zap.Int("k", 3))
+	assert.Len(t, lines(buf), 3)
+	assert.LessOrEqual(t, len(core.limiter.buckets), 2, "Expected the number of keys to be bounded")
+
+	logAt(core, zapcore.InfoLevel, "m", zap.Int("k", 3))
+	logAt(core, zapcore.InfoLevel, "m", zap.Int("k", 4))
+	logAt(core, zapcore.InfoLevel, "m", zap.Int("k", 5))
+	assert.Len(t, lines(buf), 2)
+	assert.NoError(t, core.Sync())
+	assert.Equal(t, []string{
+		`{"level":"warn","msg":"log entries suppressed by rate limit","rate_limit_key":"k=3","rate_limit_level":"info","suppressed":1}`,
+	}, lines(buf), "Expected evicted keys to keep their suppressed counts")
+}
@@ -0,0 +1,277 @@
+package cores
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	DefaultFlightRecorderSize    = 256
+	DefaultFlightRecorderMarkKey = "flight_recorder"
+
+	flightRecorderFieldKey = "glog.flightRecorder"
+)
+
+type FlightRecorderConfig struct {
+	// Size is the maximum number of buffered entries, DefaultFlightRecorderSize if zero.
+	Size int `json:"size" yaml:"size"`
+	// MaxAge drops buffered entries older than MaxAge, if set.
+	MaxAge time.Duration `json:"maxAge" yaml:"maxAge"`
+	// TriggerLevel flushes the buffer, error level if nil.
+	TriggerLevel *zapcore.Level `json:"triggerLevel" yaml:"triggerLevel"`
+	// MarkKey is the key of the field added to flushed entries.
+	MarkKey string `json:"markKey" yaml:"markKey"`
+	// Forward decides which entries are written right away. Entries must also
+	// be accepted by the wrapped core. The others are buffered and replayed
+	// through Check of the wrapped core, so a tee only writes them to the
+	// cores enabled for their level. If nil, the wrapped core alone decides,
+	// and the entries it rejects are replayed with Write, whatever its level.
+	Forward func(ent zapcore.Entry) bool `json:"-" yaml:"-"`
+}
+
+type recordedEntry struct {
+	core   zapcore.Core
+	ent    zapcore.Entry
+	fields []zapcore.Field
+}
+
+// FlightRecorder is a ring buffer of entries that were not written.
+// It is safe for concurrent use.
+type FlightRecorder struct {
+	mu      sync.Mutex
+	entries []recordedEntry
+	start   int
+	count   int
+}
+
+func NewFlightRecorder() *FlightRecorder {
+	return &FlightRecorder{}
+}
+
+func (r *FlightRecorder) add(e recordedEntry, size int, maxAge time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.entries) != size {
+		r.resize(size)
+	}
+	r.expire(e.ent.Time, maxAge)
+	if r.count == size {
+		r.entries[r.start] = recordedEntry{}
+		r.start = (r.start + 1) % size
+		r.count--
+	}
+	r.entries[(r.start+r.count)%size] = e
+	r.count++
+}
+
+// resize keeps the newest entries that fit in size.
+func (r *FlightRecorder) resize(size int) {
+	entries := make([]recordedEntry, size)
+	skip := 0
+	if r.count > size {
+		skip = r.count - size
+	}
+	n := 0
+	for i := skip; i < r.count; i++ {
+		entries[n] = r.entries[(r.start+i)%len(r.entries)]
+		n++
+	}
+	r.entries, r.start, r.count = entries, 0, n
+}
+
+func (r *FlightRecorder) expire(now time.Time, maxAge time.Duration) {
+	if maxAge <= 0 {
+		return
+	}
+	for r.count > 0 {
+		e := &r.entries[r.start]
+		if now.Sub(e.ent.Time) <= maxAge {
+			return
+		}
+		*e = recordedEntry{}
+		r.start = (r.start + 1) % len(r.entries)
+		r.count--
+	}
+}
+
+// drain removes and returns the buffered entries, oldest first.
+func (r *FlightRecorder) drain(now time.Time, maxAge time.Duration) []recordedEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.expire(now, maxAge)
+	entries := make([]recordedEntry, 0, r.count)
+	for i := 0; i < r.count; i++ {
+		idx := (r.start + i) % len(r.entries)
+		entries = append(entries, r.entries[idx])
+		r.entries[idx] = recordedEntry{}
+	}
+	r.start, r.count = 0, 0
+	return entries
+}
+
+// Len returns the number of buffered entries.
+func (r *FlightRecorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.count
+}
+
+type flightRecorderContextKey struct{}
+
+// NewFlightRecorderContext returns a copy of ctx that carries a new
+// FlightRecorder, so that entries logged with ctx are buffered apart from
+// those of other contexts.
+func NewFlightRecorderContext(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, flightRecorderContextKey{}, NewFlightRecorder())
+}
+
+func FlightRecorderFromContext(ctx context.Context) *FlightRecorder {
+	if ctx == nil {
+		return nil
+	}
+	r, _ := ctx.Value(flightRecorderContextKey{}).(*FlightRecorder)
+	return r
+}
+
+// FlightRecorderField makes a FlightRecorderCore buffer into r when passed
+// with an entry or to With. Encoders ignore it.
+func FlightRecorderField(r *FlightRecorder) zapcore.Field {
+	return zapcore.Field{Key: flightRecorderFieldKey, Type: zapcore.SkipType, Interface: r}
+}
+
+func findFlightRecorder(fields []zapcore.Field) *FlightRecorder {
+	for i := len(fields) - 1; i >= 0; i-- {
+		if fields[i].Type == zapcore.SkipType && fields[i].Key == flightRecorderFieldKey {
+			if r, ok := fields[i].Interface.(*FlightRecorder); ok {
+				return r
+			}
+		}
+	}
+	return nil
+}
+
+// FlightRecorderCore accepts entries at every level, writes those that are
+// forwarded and buffers the others. When an entry at or above the trigger
+// level arrives, the buffered entries are written first, marked with a field.
+// The buffer is shared by cores derived with With, unless a FlightRecorder is
+// passed with FlightRecorderField.
+type FlightRecorderCore struct {
+	core     zapcore.Core
+	recorder *FlightRecorder
+
+	size    int
+	maxAge  time.Duration
+	trigger zapcore.Level
+	mark    zapcore.Field
+	forward func(ent zapcore.Entry) bool
+}
+
+var _ zapcore.Core = (*FlightRecorderCore)(nil)
+
+func NewFlightRecorderCore(core zapcore.Core, cfg FlightRecorderConfig) *FlightRecorderCore {
+	c := &FlightRecorderCore{
+		core:     core,
+		recorder: NewFlightRecorder(),
+		size:     cfg.Size,
+		maxAge:   cfg.MaxAge,
+		trigger:  zapcore.ErrorLevel,
+		forward:  cfg.Forward,
+	}
+	if c.size <= 0 {
+		c.size = DefaultFlightRecorderSize
+	}
+	if cfg.TriggerLevel != nil {
+		c.trigger = *cfg.TriggerLevel
+	}
+	markKey := cfg.MarkKey
+	if markKey == "" {
+		markKey = DefaultFlightRecorderMarkKey
+	}
+	c.mark = zapcore.Field{Key: markKey, Type: zapcore.BoolType, Integer: 1}
+	return c
+}
+
+// Enabled always reports true, every entry is recorded.
+func (c *FlightRecorderCore) Enabled(zapcore.Level) bool {
+	return true
+}
+
+func (c *FlightRecorderCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.core = c.core.With(fields)
+	if r := findFlightRecorder(fields); r != nil {
+		clone.recorder = r
+	}
+	return &clone
+}
+
+func (c *FlightRecorderCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return ce.AddCore(ent, c)
+}
+
+func (c *FlightRecorderCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	recorder := c.recorder
+	if r := findFlightRecorder(fields); r != nil {
+		recorder = r
+	}
+
+	var inner *zapcore.CheckedEntry
+	forwarded := c.forward == nil || c.forward(ent)
+	if forwarded {
+		inner = c.core.Check(ent, nil)
+	}
+	// With Forward, an entry the wrapped core rejects would not be written on
+	// replay either.
+	if !forwarded || (inner == nil && c.forward == nil) {
+		recorder.add(recordedEntry{
+			core:   c.core,
+			ent:    ent,
+			fields: append([]zapcore.Field(nil), fields...),
+		}, c.size, c.maxAge)
+	}
+
+	var err error
+	if ent.Level >= c.trigger {
+		err = c.flush(recorder, ent.Time)
+	}
+	if werr := writeChecked(inner, fields); werr != nil {
+		err = werr
+	}
+	return err
+}
+
+// flush writes the buffered entries to the cores they were recorded by.
+func (c *FlightRecorderCore) flush(recorder *FlightRecorder, now time.Time) error {
+	var err error
+	for _, e := range recorder.drain(now, c.maxAge) {
+		if werr := c.replay(e); werr != nil {
+			err = werr
+		}
+	}
+	return err
+}
+
+func (c *FlightRecorderCore) replay(e recordedEntry) error {
+	fields := append(e.fields, c.mark)
+	if c.forward == nil {
+		return e.core.Write(e.ent, fields)
+	}
+	return writeChecked(e.core.Check(e.ent, nil), fields)
+}
+
+// Flush writes the entries buffered by the core without waiting for a trigger.
+func (c *FlightRecorderCore) Flush() error {
+	return c.flush(c.recorder, time.Now())
+}
+
+func (c *FlightRecorderCore) Sync() error {
+	return c.core.Sync()
+}
@@ -0,0 +1,173 @@
+package cores
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// newBufferCore returns a core writing entries at lvl and above as JSON with
+// their level and message to the returned buffer.
+func newBufferCore(lvl zapcore.Level) (zapcore.Core, *bytes.Buffer) {
+	buf := &bytes.Buffer{}
+	encoder := zapcore.NewJSONEncoder(zapcore.EncoderConfig{MessageKey: "msg", LevelKey: "level", EncodeLevel: zapcore.LowercaseLevelEncoder})
+	return zapcore.NewCore(encoder, zapcore.AddSync(buf), lvl), buf
+}
+
+func newRecorderTestCore(cfg FlightRecorderConfig) (*FlightRecorderCore, *bytes.Buffer) {
+	core, buf := newBufferCore(zapcore.InfoLevel)
+	return NewFlightRecorderCore(core, cfg), buf
+}
+
+func logAt(core zapcore.Core, lvl zapcore.Level, msg string, fields ...zapcore.Field) {
+	if ce := core.Check(zapcore.Entry{Level: lvl, Message: msg, Time: time.Now()}, nil); ce != nil {
+		ce.Write(fields...)
+	}
+}
+
+func lines(buf *bytes.Buffer) []string {
+	out := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	buf.Reset()
+	if len(out) == 1 && out[0] == "" {
+		return nil
+	}
+	return out
+}
+
+func TestFlightRecorderCore(t *testing.T) {
+	core, buf := newRecorderTestCore(FlightRecorderConfig{})
+	assert.True(t, core.Enabled(zapcore.DebugLevel), "Expected every level to be enabled")
+
+	logAt(core, zapcore.DebugLevel, "d1", zap.Int("n", 1))
+	logAt(core, zapcore.InfoLevel, "i1")
+	logAt(core, zapcore.DebugLevel, "d2")
+	assert.Equal(t, []string{`{"level":"info","msg":"i1"}`}, lines(buf), "Expected only enabled levels to be written")
+	assert.Equal(t, 2, core.recorder.Len(), "Expected debug entries to be buffered")
+
+	logAt(core, zapcore.ErrorLevel, "e1")
+	assert.Equal(t, []string{
+		`{"level":"debug","msg":"d1","n":1,"flight_recorder":true}`,
+		`{"level":"debug","msg":"d2","flight_recorder":true}`,
+		`{"level":"error","msg":"e1"}`,
+	}, lines(buf), "Expected buffered entries before the trigger entry")
+	assert.Equal(t, 0, core.recorder.Len(), "Expected the buffer to be drained")
+
+	logAt(core, zapcore.ErrorLevel, "e2")
+	assert.Equal(t, []string{`{"level":"error","msg":"e2"}`}, lines(buf), "Expected nothing to be flushed twice")
+}
+
+func TestFlightRecorderCore_size(t *testing.T) {
+	core, buf := newRecorderTestCore(FlightRecorderConfig{Size: 2, MarkKey: "replayed"})
+	for _, msg := range []string{"d1", "d2", "d3"} {
+		logAt(core, zapcore.DebugLevel, msg)
+	}
+	logAt(core, zapcore.ErrorLevel, "e")
+	assert.Equal(t, []string{
+		`{"level":"debug","msg":"d2","replayed":true}`,
+		`{"level":"debug","msg":"d3","replayed":true}`,
+		`{"level":"error","msg":"e"}`,
+	}, lines(buf), "Expected only the last two entries")
+}
+
+func TestFlightRecorderCore_maxAge(t *testing.T) {
+	core, buf := newRecorderTestCore(FlightRecorderConfig{MaxAge: time.Minute})
+	now := time.Now()
+	for _, e := range []struct {
+		msg string
+		age time.Duration
+	}{{"old", 2 * time.Minute}, {"recent", time.Second}} {
+		if ce := core.Check(zapcore.Entry{Level: zapcore.DebugLevel, Message: e.msg, Time: now.Add(-e.age)}, nil); ce != nil {
+			ce.Write()
+		}
+	}
+	if ce := core.Check(zapcore.Entry{Level: zapcore.ErrorLevel, Message: "e", Time: now}, nil); ce != nil {
+		ce.Write()
+	}
+	assert.Equal(t, []string{
+		`{"level":"debug","msg":"recent","flight_recorder":true}`,
+		`{"level":"error","msg":"e"}`,
+	}, lines(buf), "Expected old entries to be dropped")
+}
+
+func TestFlightRecorderCore_triggerAndForward(t *testing.T) {
+	warn := zapcore.WarnLevel
+	core, buf := newRecorderTestCore(FlightRecorderConfig{
+		TriggerLevel: &warn,
+		Forward: func(ent zapcore.Entry) bool {
+			return ent.Level >= zapcore.WarnLevel
+		},
+	})
+	logAt(core, zapcore.InfoLevel, "i")
+	assert.Empty(t, lines(buf), "Expected info to be buffered when not forwarded")
+	logAt(core, zapcore.WarnLevel, "w")
+	assert.Equal(t, []string{
+		`{"level":"info","msg":"i","flight_recorder":true}`,
+		`{"level":"warn","msg":"w"}`,
+	}, lines(buf), "Expected warn to trigger the flush")
+
+	logAt(core, zapcore.InfoLevel, "i2")
+	assert.NoError(t, core.Flush())
+	assert.Equal(t, []string{`{"level":"info","msg":"i2","flight_recorder":true}`}, lines(buf), "Expected Flush to write the buffer")
+}
+
+func TestFlightRecorderCore_tee(t *testing.T) {
+	encoder := zapcore.NewJSONEncoder(zapcore.EncoderConfig{MessageKey: "msg"})
+	low, high := &bytes.Buffer{}, &bytes.Buffer{}
+	tee := zapcore.NewTee(
+		zapcore.NewCore(encoder, zapcore.AddSync(low), zap.LevelEnablerFunc(func(l zapcore.Level) bool { return l <= zapcore.InfoLevel })),
+		zapcore.NewCore(encoder, zapcore.AddSync(high), zapcore.WarnLevel),
+	)
+	core := NewFlightRecorderCore(tee, FlightRecorderConfig{
+		Forward: func(ent zapcore.Entry) bool {
+			return ent.Level >= zapcore.InfoLevel
+		},
+	})
+
+	logAt(core, zapcore.DebugLevel, "d")
+	logAt(core, zapcore.ErrorLevel, "e")
+	assert.Equal(t, []string{`{"msg":"d","flight_recorder":true}`}, lines(low), "Expected the replay to reach the core enabled for debug")
+	assert.Equal(t, []string{`{"msg":"e"}`}, lines(high), "Expected the replay to skip the core disabled for debug")
+}
+
+func TestFlightRecorderCore_With(t *testing.T) {
+	core, buf := newRecorderTestCore(FlightRecorderConfig{})
+	child := core.With([]zapcore.Field{zap.String("req", "1")})
+	logAt(child, zapcore.DebugLevel, "d")
+	logAt(core, zapcore.ErrorLevel, "e")
+	assert.Equal(t, []string{
+		`{"level":"debug","msg":"d","req":"1","flight_recorder":true}`,
+		`{"level":"error","msg":"e"}`,
+	}, lines(buf), "Expected derived cores to share the buffer and keep their fields")
+}
+
+func TestFlightRecorderCore_context(t *testing.T) {
+	core, buf := newRecorderTestCore(FlightRecorderConfig{})
+	ctx1 := NewFlightRecorderContext(context.Background())
+	ctx2 := NewFlightRecorderContext(context.Background())
+	assert.NotSame(t, FlightRecorderFromContext(ctx1), FlightRecorderFromContext(ctx2), "Expected a buffer per context")
+	assert.Nil(t, FlightRecorderFromContext(context.Background()), "Expected no buffer in a plain context")
+
+	req1 := core.With([]zapcore.Field{FlightRecorderField(FlightRecorderFromContext(ctx1)), zap.String("req", "1")})
+	logAt(req1, zapcore.DebugLevel, "d1")
+	logAt(core, zapcore.DebugLevel, "d2", FlightRecorderField(FlightRecorderFromContext(ctx2)))
+	logAt(core, zapcore.DebugLevel, "d3")
+
+	logAt(req1, zapcore.ErrorLevel, "e1")
+	assert.Equal(t, []string{
+		`{"level":"debug","msg":"d1","req":"1","flight_recorder":true}`,
+		`{"level":"error","msg":"e1","req":"1"}`,
+	}, lines(buf), "Expected only the buffer of the first request")
+
+	logAt(core, zapcore.ErrorLevel, "e2", FlightRecorderField(FlightRecorderFromContext(ctx2)))
+	assert.Equal(t, []string{
+		`{"level":"debug","msg":"d2","flight_recorder":true}`,
+		`{"level":"error","msg":"e2"}`,
+	}, lines(buf), "Expected only the buffer of the second request")
+	assert.Equal(t, 1, core.recorder.Len(), "Expected the core buffer to be kept")
+}
@@ -0,0 +1,462 @@
+package cores
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const DefaultRedactMask = "****"
+
+// RedactStrategy decides how a RedactCore masks a sensitive value.
+type RedactStrategy int
+
+const (
+	// RedactFull replaces the value with the mask.
+	RedactFull RedactStrategy = iota
+	// RedactKeepLast keeps the last RedactConfig.KeepLast characters.
+	RedactKeepLast
+	// RedactHash replaces the value with a salted hash, so equal values stay correlatable.
+	RedactHash
+)
+
+var redactStrategyNames = map[RedactStrategy]string{
+	RedactFull:     "full",
+	RedactKeepLast: "keepLast",
+	RedactHash:     "hash",
+}
+
+func (s RedactStrategy) String() string {
+	if name, ok := redactStrategyNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("RedactStrategy(%d)", int(s))
+}
+
+func (s RedactStrategy) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *RedactStrategy) UnmarshalText(text []byte) error {
+	for strategy, name := range redactStrategyNames {
+		if name == string(text) {
+			*s = strategy
+			return nil
+		}
+	}
+	return fmt.Errorf("unrecognized redact strategy: %q", text)
+}
+
+// Built-in value detectors for RedactConfig.Detectors.
+const (
+	DetectEmail = "email"
+	DetectJWT   = "jwt"
+	DetectCard  = "card"
+)
+
+type RedactConfig struct {
+	// Keys are field keys whose values are masked, matched case-insensitively
+	// as exact names or path.Match globs, at any nesting depth.
+	Keys []string `json:"keys" yaml:"keys"`
+	// Detectors names built-in value detectors: email, jwt and card.
+	Detectors []string `json:"detectors" yaml:"detectors"`
+	// Patterns are regular expressions whose matches in string values are masked.
+	Patterns []string       `json:"patterns" yaml:"patterns"`
+	Strategy RedactStrategy `json:"strategy" yaml:"strategy"`
+	Mask     string         `json:"mask" yaml:"mask"`
+	KeepLast int            `json:"keepLast" yaml:"keepLast"`
+	Salt     string         `json:"salt" yaml:"salt"`
+}
+
+type valuePattern struct {
+	re    *regexp.Regexp
+	valid func(s string) bool
+}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	jwtPattern   = regexp.MustCompile(`eyJ[A-Za-z0-9_\-]+\.eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+`)
+	cardPattern  = regexp.MustCompile(`\b\d(?:[ \-]?\d){12,18}\b`)
+)
+
+// luhn reports whether the digits in s pass the Luhn checksum.
+func luhn(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
+
+// Redactor masks sensitive fields. It is safe for concurrent use.
+type Redactor struct {
+	exact    map[string]struct{}
+	globs    []string
+	patterns []valuePattern
+	strategy RedactStrategy
+	mask     string
+	keepLast int
+	salt     []byte
+}
+
+func NewRedactor(cfg RedactConfig) (*Redactor, error) {
+	r := &Redactor{
+		exact:    make(map[string]struct{}, len(cfg.Keys)),
+		strategy: cfg.Strategy,
+		mask:     cfg.Mask,
+		keepLast: cfg.KeepLast,
+		salt:     []byte(cfg.Salt),
+	}
+	if r.mask == "" {
+		r.mask = DefaultRedactMask
+	}
+
+	for _, key := range cfg.Keys {
+		key = strings.ToLower(key)
+		if !strings.ContainsAny(key, `*?[\`) {
+			r.exact[key] = struct{}{}
+			continue
+		}
+		if _, err := path.Match(key, ""); err != nil {
+			return nil, fmt.Errorf("invalid redact key pattern %q: %w", key, err)
+		}
+		r.globs = append(r.globs, key)
+	}
+
+	for _, name := range cfg.Detectors {
+		switch name {
+		case DetectEmail:
+			r.patterns = append(r.patterns, valuePattern{re: emailPattern})
+		case DetectJWT:
+			r.patterns = append(r.patterns, valuePattern{re: jwtPattern})
+		case DetectCard:
+			r.patterns = append(r.patterns, valuePattern{re: cardPattern, valid: luhn})
+		default:
+			return nil, fmt.Errorf("unrecognized redact detector: %q", name)
+		}
+	}
+	for _, p := range cfg.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q: %w", p, err)
+		}
+		r.patterns = append(r.patterns, valuePattern{re: re})
+	}
+	return r, nil
+}
+
+func (r *Redactor) matchKey(key string) bool {
+	if len(r.exact) == 0 && len(r.globs) == 0 {
+		return false
+	}
+	key = strings.ToLower(key)
+	if _, ok := r.exact[key]; ok {
+		return true
+	}
+	for _, g := range r.globs {
+		if ok, _ := path.Match(g, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Mask masks s with the configured strategy.
+func (r *Redactor) Mask(s string) string {
+	switch r.strategy {
+	case RedactKeepLast:
+		n := utf8.RuneCountInString(s)
+		if r.keepLast <= 0 || n <= r.keepLast {
+			return r.mask
+		}
+		runes := []rune(s)
+		return r.mask + string(runes[n-r.keepLast:])
+	case RedactHash:
+		h := hmac.New(sha256.New, r.salt)
+		h.Write([]byte(s))
+		return "sha256:" + hex.EncodeToString(h.Sum(nil))[:16]
+	default:
+		return r.mask
+	}
+}
+
+// redactString masks every match of the value patterns in s.
+func (r *Redactor) redactString(s string) (string, bool) {
+	changed := false
+	for _, p := range r.patterns {
+		s = p.re.ReplaceAllStringFunc(s, func(m string) string {
+			if p.valid != nil && !p.valid(m) {
+				return m
+			}
+			changed = true
+			return r.Mask(m)
+		})
+	}
+	return s, changed
+}
+
+// redactValue walks the generic values produced by encoding objects and
+// reflected values into maps.
+func (r *Redactor) redactValue(v any) (any, bool) {
+	switch val := v.(type) {
+	case string:
+		return r.redactString(val)
+	case map[string]any:
+		out, changed := make(map[string]any, len(val)), false
+		for k, e := range val {
+			if r.matchKey(k) {
+				out[k], changed = r.Mask(valueString(e)), true
+				continue
+			}
+			redacted, ok := r.redactValue(e)
+			out[k], changed = redacted, changed || ok
+		}
+		return out, changed
+	case []any:
+		out, changed := make([]any, len(val)), false
+		for i, e := range val {
+			redacted, ok := r.redactValue(e)
+			out[i], changed = redacted, changed || ok
+		}
+		return out, changed
+	case nil:
+		return v, false
+	default:
+		// Reflected values stay as they are inside encoded objects.
+		switch reflect.TypeOf(v).Kind() {
+		case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array, reflect.Ptr, reflect.Interface:
+			if generic, ok := reflectedValue(v); ok {
+				if redacted, ok := r.redactValue(generic); ok {
+					return redacted, true
+				}
+			}
+		}
+		return v, false
+	}
+}
+
+func valueString(v any) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case []byte:
+		return string(val)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(b)
+	}
+}
+
+// fieldValue returns the generic value f encodes to.
+func fieldValue(f zapcore.Field) any {
+	enc := zapcore.NewMapObjectEncoder()
+	key := f.Key
+	if f.Type == zapcore.InlineMarshalerType {
+		key = "inline"
+		f = zapcore.Field{Key: key, Type: zapcore.ObjectMarshalerType, Interface: f.Interface}
+	}
+	f.AddTo(enc)
+	return enc.Fields[key]
+}
+
+// reflectedValue converts v to the generic value its JSON form decodes to.
+func reflectedValue(v any) (any, bool) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, false
+	}
+	var out any
+	if err = json.Unmarshal(b, &out); err != nil {
+		return nil, false
+	}
+	return out, true
+}
+
+// redactedObject writes the redacted form of an object.
+type redactedObject map[string]any
+
+func (o redactedObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	keys := make([]string, 0, len(o))
+	for k := range o {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := enc.AddReflected(k, o[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Redactor) redactField(f zapcore.Field) (zapcore.Field, bool) {
+	switch f.Type {
+	case zapcore.SkipType, zapcore.NamespaceType:
+		return f, false
+	}
+
+	if f.Key != "" && r.matchKey(f.Key) {
+		s := f.String
+		if f.Type != zapcore.StringType {
+			s = valueString(fieldValue(f))
+		}
+		return zapcore.Field{Key: f.Key, Type: zapcore.StringType, String: r.Mask(s)}, true
+	}
+	if len(r.patterns) == 0 && len(r.exact) == 0 && len(r.globs) == 0 {
+		return f, false
+	}
+
+	switch f.Type {
+	case zapcore.StringType:
+		if s, ok := r.redactString(f.String); ok {
+			f.String = s
+			return f, true
+		}
+	case zapcore.ByteStringType, zapcore.StringerType, zapcore.ErrorType:
+		if s, ok := fieldValue(f).(string); ok {
+			if s, ok = r.redactString(s); ok {
+				return zapcore.Field{Key: f.Key, Type: zapcore.StringType, String: s}, true
+			}
+		}
+	case zapcore.ObjectMarshalerType, zapcore.InlineMarshalerType:
+		if m, ok := fieldValue(f).(map[string]any); ok {
+			if v, ok := r.redactValue(m); ok {
+				f.Interface = redactedObject(v.(map[string]any))
+				return f, true
+			}
+		}
+	case zapcore.ArrayMarshalerType:
+		if v, ok := r.redactValue(fieldValue(f)); ok {
+			return zapcore.Field{Key: f.Key, Type: zapcore.ReflectType, Interface: v}, true
+		}
+	case zapcore.ReflectType:
+		if generic, ok := reflectedValue(f.Interface); ok {
+			if v, ok := r.redactValue(generic); ok {
+				return zapcore.Field{Key: f.Key, Type: zapcore.ReflectType, Interface: v}, true
+			}
+		}
+	}
+	return f, false
+}
+
+// Redact returns fields with sensitive values masked. fields is not modified.
+func (r *Redactor) Redact(fields []zapcore.Field) []zapcore.Field {
+	var out []zapcore.Field
+	for i, f := range fields {
+		redacted, ok := r.redactField(f)
+		if !ok {
+			if out != nil {
+				out = append(out, f)
+			}
+			continue
+		}
+		if out == nil {
+			out = make([]zapcore.Field, i, len(fields))
+			copy(out, fields[:i])
+		}
+		out = append(out, redacted)
+	}
+	if out == nil {
+		return fields
+	}
+	return out
+}
+
+// RedactCore masks secrets and personal data in fields added with With and
+// in the fields of every entry before they reach the wrapped core.
+type RedactCore struct {
+	core zapcore.Core
+	r    *Redactor
+}
+
+var _ zapcore.Core = (*RedactCore)(nil)
+
+func NewRedactCore(core zapcore.Core, r *Redactor) *RedactCore {
+	return &RedactCore{
+		core: core,
+		r:    r,
+	}
+}
+
+func (c *RedactCore) Enabled(lvl zapcore.Level) bool {
+	return c.core.Enabled(lvl)
+}
+
+func (c *RedactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &RedactCore{
+		core: c.core.With(c.r.Redact(fields)),
+		r:    c.r,
+	}
+}
+
+func (c *RedactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write checks the entry against the wrapped core again, so that cores with
+// their own levels, such as a tee, only receive what they accept.
+func (c *RedactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	inner := c.core.Check(ent, nil)
+	if inner == nil {
+		return nil
+	}
+	return writeChecked(inner, c.r.Redact(fields))
+}
+
+func (c *RedactCore) Sync() error {
+	return c.core.Sync()
+}
+
+// errorCollector turns what a CheckedEntry reports on its ErrorOutput into an error.
+type errorCollector struct {
+	err error
+}
+
+func (e *errorCollector) Write(p []byte) (int, error) {
+	e.err = errors.New(strings.TrimSpace(string(p)))
+	return len(p), nil
+}
+
+func (e *errorCollector) Sync() error {
+	return nil
+}
+
+// writeChecked writes ce, if not nil, and returns the error it reports.
+func writeChecked(ce *zapcore.CheckedEntry, fields []zapcore.Field) error {
+	if ce == nil {
+		return nil
+	}
+	errOut := &errorCollector{}
+	ce.ErrorOutput = errOut
+	ce.Write(fields...)
+	return errOut.err
+}
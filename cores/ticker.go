@@ -0,0 +1,63 @@
+package cores
+
+import (
+	"sync"
+	"time"
+)
+
+// ticker runs a function periodically in a goroutine that is started on
+// demand and stopped by stop. Cores use it to write what they hold back
+// without waiting for the next entry.
+type ticker struct {
+	newTicker func(d time.Duration) (<-chan time.Time, func())
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	done      chan struct{}
+	stopped   chan struct{}
+}
+
+func newTicker() *ticker {
+	return &ticker{
+		newTicker: newTimeTicker,
+		done:      make(chan struct{}),
+	}
+}
+
+func newTimeTicker(d time.Duration) (<-chan time.Time, func()) {
+	t := time.NewTicker(d)
+	return t.C, t.Stop
+}
+
+// start runs f every interval until stop is called. Only the first call
+// starts the goroutine.
+func (t *ticker) start(interval time.Duration, f func()) {
+	t.startOnce.Do(func() {
+		ticks, stopTicks := t.newTicker(interval)
+		t.stopped = make(chan struct{})
+		go func() {
+			defer close(t.stopped)
+			defer stopTicks()
+			for {
+				select {
+				case <-ticks:
+					f()
+				case <-t.done:
+					return
+				}
+			}
+		}()
+	})
+}
+
+// stop stops the goroutine and waits for it to return. The goroutine is not
+// started afterwards.
+func (t *ticker) stop() {
+	t.stopOnce.Do(func() {
+		close(t.done)
+	})
+	t.startOnce.Do(func() {})
+	if t.stopped != nil {
+		<-t.stopped
+	}
+}
@@ -0,0 +1,73 @@
+package glog
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ace-zhaoy/glog/encoders"
+	"go.uber.org/zap/zapcore"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// EncoderConstructor builds an encoder from the EncoderConfig of a CoreConfig.
+type EncoderConstructor func(EncoderConfig) (zapcore.Encoder, error)
+
+var (
+	_encoderMutex             sync.RWMutex
+	_encoderNameToConstructor = map[string]EncoderConstructor{
+		"json": func(cfg EncoderConfig) (zapcore.Encoder, error) {
+			return zapcore.NewJSONEncoder(cfg), nil
+		},
+		"console": func(cfg EncoderConfig) (zapcore.Encoder, error) {
+			return zapcore.NewConsoleEncoder(cfg), nil
+		},
+		"logfmt": func(cfg EncoderConfig) (zapcore.Encoder, error) {
+			return encoders.NewLogfmtEncoder(cfg), nil
+		},
+	}
+)
+
+// RegisterEncoder registers an encoder constructor that can then be selected
+// by name in CoreConfig.Encoding. Registering a name twice is an error.
+func RegisterEncoder(name string, constructor EncoderConstructor) error {
+	if name == "" {
+		return errors.New("encoder name must not be empty")
+	}
+	if constructor == nil {
+		return fmt.Errorf("encoder constructor for %q must not be nil", name)
+	}
+
+	_encoderMutex.Lock()
+	defer _encoderMutex.Unlock()
+
+	if _, ok := _encoderNameToConstructor[name]; ok {
+		return fmt.Errorf("encoder already registered for name %q", name)
+	}
+	_encoderNameToConstructor[name] = constructor
+	return nil
+}
+
+// RegisteredEncoders returns the sorted names of all registered encoders.
+func RegisteredEncoders() []string {
+	_encoderMutex.RLock()
+	defer _encoderMutex.RUnlock()
+
+	names := make([]string, 0, len(_encoderNameToConstructor))
+	for name := range _encoderNameToConstructor {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func newEncoder(name string, cfg EncoderConfig) (zapcore.Encoder, error) {
+	_encoderMutex.RLock()
+	constructor, ok := _encoderNameToConstructor[name]
+	_encoderMutex.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unsupported encoding: %s (registered: %s)", name, strings.Join(RegisteredEncoders(), ", "))
+	}
+	return constructor(cfg)
+}
@@ -0,0 +1,72 @@
+package glog
+
+import (
+	"errors"
+	"github.com/ace-zhaoy/glog/encoders"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+	"sync"
+	"testing"
+)
+
+func TestRegisterEncoder(t *testing.T) {
+	called := false
+	err := RegisterEncoder("test-envelope", func(cfg EncoderConfig) (zapcore.Encoder, error) {
+		called = true
+		return encoders.NewLogfmtEncoder(cfg), nil
+	})
+	assert.NoError(t, err, "Expected no error when registering a new encoder")
+	assert.Contains(t, RegisteredEncoders(), "test-envelope")
+
+	cfg := CoreConfig{Encoding: "test-envelope"}
+	enc, err := cfg.buildEncoder()
+	assert.NoError(t, err, "Expected no error when building a registered encoder")
+	assert.NotNil(t, enc)
+	assert.True(t, called, "Expected the registered constructor to be used")
+
+	err = RegisterEncoder("test-envelope", func(cfg EncoderConfig) (zapcore.Encoder, error) {
+		return nil, nil
+	})
+	assert.Error(t, err, "Expected error for duplicate registration")
+	assert.Error(t, RegisterEncoder("json", func(cfg EncoderConfig) (zapcore.Encoder, error) { return nil, nil }), "Expected error when overriding a built-in encoder")
+	assert.Error(t, RegisterEncoder("", func(cfg EncoderConfig) (zapcore.Encoder, error) { return nil, nil }), "Expected error for empty name")
+	assert.Error(t, RegisterEncoder("nil-constructor", nil), "Expected error for nil constructor")
+}
+
+func TestRegisterEncoder_Concurrent(t *testing.T) {
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- RegisterEncoder("test-concurrent", func(cfg EncoderConfig) (zapcore.Encoder, error) {
+				return zapcore.NewJSONEncoder(cfg), nil
+			})
+			_, _ = newEncoder("json", EncoderConfig{})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		if err == nil {
+			succeeded++
+		}
+	}
+	assert.Equal(t, 1, succeeded, "Expected exactly one registration to succeed")
+}
+
+func Test_newEncoder(t *testing.T) {
+	_, err := newEncoder("unknown", EncoderConfig{})
+	assert.ErrorContains(t, err, "unsupported encoding: unknown")
+	assert.ErrorContains(t, err, "console, json, logfmt", "Expected error to list the registered encoders")
+
+	failing := errors.New("bad config")
+	assert.NoError(t, RegisterEncoder("test-failing", func(cfg EncoderConfig) (zapcore.Encoder, error) {
+		return nil, failing
+	}))
+	_, err = newEncoder("test-failing", EncoderConfig{})
+	assert.ErrorIs(t, err, failing, "Expected constructor error to be returned")
+}
@@ -0,0 +1,510 @@
+package encoders
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+const hex = "0123456789abcdef"
+
+var (
+	_bufferPool = buffer.NewPool()
+
+	_logfmtPool = sync.Pool{New: func() any {
+		return &logfmtEncoder{}
+	}}
+)
+
+// NewLogfmtEncoder creates an encoder that writes entries as logfmt
+// key=value pairs separated by spaces. Keys of namespaces and nested objects
+// are flattened with dots, arrays are written as [a,b,c] and values are
+// quoted when they contain spaces, quotes, '=' or control characters.
+func NewLogfmtEncoder(cfg zapcore.EncoderConfig) zapcore.Encoder {
+	return newLogfmtEncoder(cfg)
+}
+
+func newLogfmtEncoder(cfg zapcore.EncoderConfig) *logfmtEncoder {
+	enc := &logfmtEncoder{
+		EncoderConfig: &cfg,
+		buf:           _bufferPool.Get(),
+		sep:           ' ',
+	}
+	enc.value.enc = enc
+	return enc
+}
+
+type logfmtEncoder struct {
+	*zapcore.EncoderConfig
+	buf    *buffer.Buffer
+	prefix string
+	sep    byte
+	inner  bool
+
+	// value writes a single value right after a key.
+	value logfmtArrayEncoder
+}
+
+func getLogfmtEncoder() *logfmtEncoder {
+	return _logfmtPool.Get().(*logfmtEncoder)
+}
+
+func putLogfmtEncoder(enc *logfmtEncoder) {
+	enc.EncoderConfig = nil
+	enc.buf = nil
+	enc.prefix = ""
+	enc.sep = 0
+	enc.inner = false
+	enc.value = logfmtArrayEncoder{}
+	_logfmtPool.Put(enc)
+}
+
+// subEncoder returns an encoder for an object nested in an array.
+func (enc *logfmtEncoder) subEncoder(buf *buffer.Buffer) *logfmtEncoder {
+	sub := getLogfmtEncoder()
+	sub.EncoderConfig = enc.EncoderConfig
+	sub.buf = buf
+	sub.sep = ','
+	sub.inner = true
+	sub.value.enc = sub
+	return sub
+}
+
+func (enc *logfmtEncoder) clone() *logfmtEncoder {
+	c := getLogfmtEncoder()
+	c.EncoderConfig = enc.EncoderConfig
+	c.buf = _bufferPool.Get()
+	c.prefix = enc.prefix
+	c.sep = enc.sep
+	c.inner = enc.inner
+	c.value.enc = c
+	return c
+}
+
+func (enc *logfmtEncoder) Clone() zapcore.Encoder {
+	c := enc.clone()
+	_, _ = c.buf.Write(enc.buf.Bytes())
+	return c
+}
+
+func (enc *logfmtEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	final := enc.clone()
+	final.prefix = ""
+
+	if final.LevelKey != "" && final.EncodeLevel != nil {
+		final.addKey(final.LevelKey)
+		cur := final.buf.Len()
+		final.EncodeLevel(ent.Level, final.valueEncoder())
+		if cur == final.buf.Len() {
+			final.appendString(ent.Level.String())
+		}
+	}
+	if final.TimeKey != "" {
+		final.AddTime(final.TimeKey, ent.Time)
+	}
+	if ent.LoggerName != "" && final.NameKey != "" {
+		final.addKey(final.NameKey)
+		cur := final.buf.Len()
+		nameEncoder := final.EncodeName
+		if nameEncoder == nil {
+			nameEncoder = zapcore.FullNameEncoder
+		}
+		nameEncoder(ent.LoggerName, final.valueEncoder())
+		if cur == final.buf.Len() {
+			final.appendString(ent.LoggerName)
+		}
+	}
+	if ent.Caller.Defined {
+		if final.CallerKey != "" {
+			final.addKey(final.CallerKey)
+			cur := final.buf.Len()
+			if final.EncodeCaller != nil {
+				final.EncodeCaller(ent.Caller, final.valueEncoder())
+			}
+			if cur == final.buf.Len() {
+				final.appendString(ent.Caller.String())
+			}
+		}
+		if final.FunctionKey != "" {
+			final.AddString(final.FunctionKey, ent.Caller.Function)
+		}
+	}
+	if final.MessageKey != "" {
+		final.AddString(final.MessageKey, ent.Message)
+	}
+	if enc.buf.Len() > 0 {
+		if final.buf.Len() > 0 {
+			final.buf.AppendByte(final.sep)
+		}
+		_, _ = final.buf.Write(enc.buf.Bytes())
+	}
+
+	final.prefix = enc.prefix
+	for i := range fields {
+		fields[i].AddTo(final)
+	}
+	final.prefix = ""
+
+	if ent.Stack != "" && final.StacktraceKey != "" {
+		final.AddString(final.StacktraceKey, ent.Stack)
+	}
+	if !final.SkipLineEnding {
+		if final.LineEnding != "" {
+			final.buf.AppendString(final.LineEnding)
+		} else {
+			final.buf.AppendString(zapcore.DefaultLineEnding)
+		}
+	}
+
+	ret := final.buf
+	putLogfmtEncoder(final)
+	return ret, nil
+}
+
+func (enc *logfmtEncoder) addKey(key string) {
+	if enc.buf.Len() > 0 {
+		enc.buf.AppendByte(enc.sep)
+	}
+	appendKey(enc.buf, enc.prefix)
+	appendKey(enc.buf, key)
+	enc.buf.AppendByte('=')
+}
+
+func (enc *logfmtEncoder) valueEncoder() *logfmtArrayEncoder {
+	enc.value = logfmtArrayEncoder{enc: enc, buf: enc.buf, inner: enc.inner}
+	return &enc.value
+}
+
+func (enc *logfmtEncoder) appendString(s string) {
+	appendValue(enc.buf, s, enc.inner)
+}
+
+func (enc *logfmtEncoder) encodeReflected(obj any) (string, error) {
+	buf := _bufferPool.Get()
+	defer buf.Free()
+
+	var re zapcore.ReflectedEncoder
+	if enc.NewReflectedEncoder != nil {
+		re = enc.NewReflectedEncoder(buf)
+	} else {
+		je := json.NewEncoder(buf)
+		je.SetEscapeHTML(false)
+		re = je
+	}
+	if err := re.Encode(obj); err != nil {
+		return "", err
+	}
+	return string(bytes.TrimRight(buf.Bytes(), "\n")), nil
+}
+
+func (enc *logfmtEncoder) AddArray(key string, arr zapcore.ArrayMarshaler) error {
+	buf := _bufferPool.Get()
+	defer buf.Free()
+
+	err := (&logfmtArrayEncoder{enc: enc, buf: buf, inner: true}).AppendArray(arr)
+	enc.addKey(key)
+	enc.appendString(buf.String())
+	return err
+}
+
+func (enc *logfmtEncoder) AddObject(key string, obj zapcore.ObjectMarshaler) error {
+	prefix := enc.prefix
+	enc.prefix = prefix + key + "."
+	err := obj.MarshalLogObject(enc)
+	enc.prefix = prefix
+	return err
+}
+
+func (enc *logfmtEncoder) AddBinary(key string, val []byte) {
+	enc.AddString(key, base64.StdEncoding.EncodeToString(val))
+}
+
+func (enc *logfmtEncoder) AddByteString(key string, val []byte) {
+	enc.addKey(key)
+	enc.valueEncoder().AppendByteString(val)
+}
+
+func (enc *logfmtEncoder) AddBool(key string, val bool) {
+	enc.addKey(key)
+	enc.valueEncoder().AppendBool(val)
+}
+
+func (enc *logfmtEncoder) AddComplex128(key string, val complex128) {
+	enc.addKey(key)
+	enc.valueEncoder().AppendComplex128(val)
+}
+
+func (enc *logfmtEncoder) AddComplex64(key string, val complex64) {
+	enc.AddComplex128(key, complex128(val))
+}
+
+func (enc *logfmtEncoder) AddDuration(key string, val time.Duration) {
+	enc.addKey(key)
+	enc.valueEncoder().AppendDuration(val)
+}
+
+func (enc *logfmtEncoder) AddFloat64(key string, val float64) {
+	enc.addKey(key)
+	enc.valueEncoder().AppendFloat64(val)
+}
+
+func (enc *logfmtEncoder) AddFloat32(key string, val float32) {
+	enc.addKey(key)
+	enc.valueEncoder().AppendFloat32(val)
+}
+
+func (enc *logfmtEncoder) AddInt(key string, val int) { enc.AddInt64(key, int64(val)) }
+
+func (enc *logfmtEncoder) AddInt64(key string, val int64) {
+	enc.addKey(key)
+	enc.buf.AppendInt(val)
+}
+
+func (enc *logfmtEncoder) AddInt32(key string, val int32) { enc.AddInt64(key, int64(val)) }
+func (enc *logfmtEncoder) AddInt16(key string, val int16) { enc.AddInt64(key, int64(val)) }
+func (enc *logfmtEncoder) AddInt8(key string, val int8)   { enc.AddInt64(key, int64(val)) }
+
+func (enc *logfmtEncoder) AddString(key, val string) {
+	enc.addKey(key)
+	enc.appendString(val)
+}
+
+func (enc *logfmtEncoder) AddTime(key string, val time.Time) {
+	enc.addKey(key)
+	enc.valueEncoder().AppendTime(val)
+}
+
+func (enc *logfmtEncoder) AddUint(key string, val uint) { enc.AddUint64(key, uint64(val)) }
+
+func (enc *logfmtEncoder) AddUint64(key string, val uint64) {
+	enc.addKey(key)
+	enc.buf.AppendUint(val)
+}
+
+func (enc *logfmtEncoder) AddUint32(key string, val uint32)   { enc.AddUint64(key, uint64(val)) }
+func (enc *logfmtEncoder) AddUint16(key string, val uint16)   { enc.AddUint64(key, uint64(val)) }
+func (enc *logfmtEncoder) AddUint8(key string, val uint8)     { enc.AddUint64(key, uint64(val)) }
+func (enc *logfmtEncoder) AddUintptr(key string, val uintptr) { enc.AddUint64(key, uint64(val)) }
+
+func (enc *logfmtEncoder) AddReflected(key string, obj interface{}) error {
+	s, err := enc.encodeReflected(obj)
+	if err != nil {
+		return err
+	}
+	enc.AddString(key, s)
+	return nil
+}
+
+func (enc *logfmtEncoder) OpenNamespace(key string) {
+	enc.prefix += key + "."
+}
+
+// logfmtArrayEncoder writes comma-separated values. It is used for arrays and
+// for the single values written by the EncoderConfig encoders.
+type logfmtArrayEncoder struct {
+	enc   *logfmtEncoder
+	buf   *buffer.Buffer
+	inner bool
+	n     int
+}
+
+func (a *logfmtArrayEncoder) addSeparator() {
+	if a.n > 0 {
+		a.buf.AppendByte(',')
+	}
+	a.n++
+}
+
+func (a *logfmtArrayEncoder) AppendArray(arr zapcore.ArrayMarshaler) error {
+	a.addSeparator()
+	a.buf.AppendByte('[')
+	err := arr.MarshalLogArray(&logfmtArrayEncoder{enc: a.enc, buf: a.buf, inner: true})
+	a.buf.AppendByte(']')
+	return err
+}
+
+func (a *logfmtArrayEncoder) AppendObject(obj zapcore.ObjectMarshaler) error {
+	a.addSeparator()
+	sub := a.enc.subEncoder(_bufferPool.Get())
+	err := obj.MarshalLogObject(sub)
+	a.buf.AppendByte('{')
+	_, _ = a.buf.Write(sub.buf.Bytes())
+	a.buf.AppendByte('}')
+	sub.buf.Free()
+	putLogfmtEncoder(sub)
+	return err
+}
+
+func (a *logfmtArrayEncoder) AppendReflected(val interface{}) error {
+	s, err := a.enc.encodeReflected(val)
+	if err != nil {
+		return err
+	}
+	a.AppendString(s)
+	return nil
+}
+
+func (a *logfmtArrayEncoder) AppendBool(val bool) {
+	a.addSeparator()
+	a.buf.AppendBool(val)
+}
+
+func (a *logfmtArrayEncoder) AppendByteString(val []byte) {
+	a.addSeparator()
+	appendValue(a.buf, string(val), a.inner)
+}
+
+func (a *logfmtArrayEncoder) AppendComplex128(val complex128) {
+	a.addSeparator()
+	r, i := real(val), imag(val)
+	a.buf.AppendFloat(r, 64)
+	if i >= 0 || math.IsNaN(i) {
+		a.buf.AppendByte('+')
+	}
+	a.buf.AppendFloat(i, 64)
+	a.buf.AppendByte('i')
+}
+
+func (a *logfmtArrayEncoder) AppendComplex64(val complex64) { a.AppendComplex128(complex128(val)) }
+
+func (a *logfmtArrayEncoder) AppendDuration(val time.Duration) {
+	cur := a.buf.Len()
+	if e := a.enc.EncodeDuration; e != nil {
+		e(val, a)
+	}
+	if cur == a.buf.Len() {
+		a.AppendInt64(int64(val))
+	}
+}
+
+func (a *logfmtArrayEncoder) AppendFloat64(val float64) {
+	a.addSeparator()
+	a.buf.AppendFloat(val, 64)
+}
+
+func (a *logfmtArrayEncoder) AppendFloat32(val float32) {
+	a.addSeparator()
+	a.buf.AppendFloat(float64(val), 32)
+}
+
+func (a *logfmtArrayEncoder) AppendInt(val int)     { a.AppendInt64(int64(val)) }
+func (a *logfmtArrayEncoder) AppendInt32(val int32) { a.AppendInt64(int64(val)) }
+func (a *logfmtArrayEncoder) AppendInt16(val int16) { a.AppendInt64(int64(val)) }
+func (a *logfmtArrayEncoder) AppendInt8(val int8)   { a.AppendInt64(int64(val)) }
+
+func (a *logfmtArrayEncoder) AppendInt64(val int64) {
+	a.addSeparator()
+	a.buf.AppendInt(val)
+}
+
+func (a *logfmtArrayEncoder) AppendString(val string) {
+	a.addSeparator()
+	appendValue(a.buf, val, a.inner)
+}
+
+func (a *logfmtArrayEncoder) AppendTime(val time.Time) {
+	cur := a.buf.Len()
+	if e := a.enc.EncodeTime; e != nil {
+		e(val, a)
+	}
+	if cur == a.buf.Len() {
+		a.AppendInt64(val.UnixNano())
+	}
+}
+
+func (a *logfmtArrayEncoder) AppendUint(val uint)       { a.AppendUint64(uint64(val)) }
+func (a *logfmtArrayEncoder) AppendUint32(val uint32)   { a.AppendUint64(uint64(val)) }
+func (a *logfmtArrayEncoder) AppendUint16(val uint16)   { a.AppendUint64(uint64(val)) }
+func (a *logfmtArrayEncoder) AppendUint8(val uint8)     { a.AppendUint64(uint64(val)) }
+func (a *logfmtArrayEncoder) AppendUintptr(val uintptr) { a.AppendUint64(uint64(val)) }
+
+func (a *logfmtArrayEncoder) AppendUint64(val uint64) {
+	a.addSeparator()
+	a.buf.AppendUint(val)
+}
+
+// appendKey writes key, replacing the characters that would break a logfmt key with '_'.
+func appendKey(buf *buffer.Buffer, key string) {
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if c <= ' ' || c == '=' || c == '"' || c == 0x7f {
+			c = '_'
+		}
+		buf.AppendByte(c)
+	}
+}
+
+func needsQuote(s string, inner bool) bool {
+	if s == "" {
+		return true
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c <= ' ', c == '=', c == '"', c == 0x7f:
+			return true
+		case c >= utf8.RuneSelf:
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if r == utf8.RuneError && size == 1 {
+				return true
+			}
+			i += size - 1
+		case inner && (c == ',' || c == '[' || c == ']' || c == '{' || c == '}'):
+			return true
+		}
+	}
+	return false
+}
+
+// appendValue writes s, quoting and escaping it when needed. Values nested in
+// arrays are also quoted when they contain array or object delimiters.
+func appendValue(buf *buffer.Buffer, s string, inner bool) {
+	if !needsQuote(s, inner) {
+		buf.AppendString(s)
+		return
+	}
+
+	buf.AppendByte('"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			switch c {
+			case '\\', '"':
+				buf.AppendByte('\\')
+				buf.AppendByte(c)
+			case '\n':
+				buf.AppendString(`\n`)
+			case '\r':
+				buf.AppendString(`\r`)
+			case '\t':
+				buf.AppendString(`\t`)
+			default:
+				if c < ' ' || c == 0x7f {
+					buf.AppendString(`\u00`)
+					buf.AppendByte(hex[c>>4])
+					buf.AppendByte(hex[c&0xF])
+				} else {
+					buf.AppendByte(c)
+				}
+			}
+			i++
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf.AppendString("\ufffd")
+		} else {
+			buf.AppendString(s[i : i+size])
+		}
+		i += size
+	}
+	buf.AppendByte('"')
+}
@@ -0,0 +1,307 @@
+package encoders
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// decodeLogfmt parses a logfmt line back into its pairs, in order.
+func decodeLogfmt(t *testing.T, line string) [][2]string {
+	t.Helper()
+	var pairs [][2]string
+	for i := 0; i < len(line); {
+		if line[i] == ' ' {
+			i++
+			continue
+		}
+		eq := strings.IndexByte(line[i:], '=')
+		require.GreaterOrEqual(t, eq, 0, "missing '=' in %q", line[i:])
+		key := line[i : i+eq]
+		i += eq + 1
+
+		var value string
+		if i < len(line) && line[i] == '"' {
+			end := i + 1
+			for ; end < len(line) && line[end] != '"'; end++ {
+				if line[end] == '\\' {
+					end++
+				}
+			}
+			require.Less(t, end, len(line), "unterminated quote in %q", line)
+			unquoted, err := strconv.Unquote(line[i : end+1])
+			require.NoError(t, err, "invalid quoted value %q", line[i:end+1])
+			value, i = unquoted, end+1
+		} else {
+			end := strings.IndexByte(line[i:], ' ')
+			if end < 0 {
+				end = len(line) - i
+			}
+			value, i = line[i:i+end], i+end
+		}
+		pairs = append(pairs, [2]string{key, value})
+	}
+	return pairs
+}
+
+func testEncoderConfig() zapcore.EncoderConfig {
+	return zapcore.EncoderConfig{
+		MessageKey:     "msg",
+		LevelKey:       "level",
+		TimeKey:        "ts",
+		NameKey:        "logger",
+		CallerKey:      "caller",
+		FunctionKey:    "func",
+		StacktraceKey:  "stacktrace",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    zapcore.LowercaseLevelEncoder,
+		EncodeTime:     zapcore.ISO8601TimeEncoder,
+		EncodeDuration: zapcore.StringDurationEncoder,
+		EncodeCaller:   zapcore.ShortCallerEncoder,
+	}
+}
+
+type testObject struct {
+	name  string
+	count int
+}
+
+func (o testObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("name", o.name)
+	enc.AddInt("count", o.count)
+	return nil
+}
+
+type failingArray struct{}
+
+func (failingArray) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	enc.AppendString("partial")
+	return errors.New("broken")
+}
+
+func encodeFields(t *testing.T, fields ...zapcore.Field) string {
+	t.Helper()
+	enc := NewLogfmtEncoder(zapcore.EncoderConfig{SkipLineEnding: true})
+	buf, err := enc.EncodeEntry(zapcore.Entry{}, fields)
+	require.NoError(t, err)
+	defer buf.Free()
+	return buf.String()
+}
+
+func TestLogfmtEncoder_Fields(t *testing.T) {
+	ts := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		field zapcore.Field
+		want  string
+	}{
+		{name: "string", field: zap.String("k", "v"), want: `k=v`},
+		{name: "empty string", field: zap.String("k", ""), want: `k=""`},
+		{name: "string with space", field: zap.String("k", "a b"), want: `k="a b"`},
+		{name: "string with quote", field: zap.String("k", `say "hi"`), want: `k="say \"hi\""`},
+		{name: "string with equals", field: zap.String("k", "a=b"), want: `k="a=b"`},
+		{name: "string with newline", field: zap.String("k", "a\nb\tc\r"), want: `k="a\nb\tc\r"`},
+		{name: "string with control", field: zap.String("k", "a\x01"), want: `k="a\u0001"`},
+		{name: "string with backslash", field: zap.String("k", `a\b`), want: `k=a\b`},
+		{name: "unicode string", field: zap.String("k", "héllo"), want: `k=héllo`},
+		{name: "invalid utf8", field: zap.String("k", "a\xffb"), want: "k=\"a\ufffdb\""},
+		{name: "key with space", field: zap.String("a key=", "v"), want: `a_key_=v`},
+		{name: "bool", field: zap.Bool("k", true), want: `k=true`},
+		{name: "int", field: zap.Int("k", -42), want: `k=-42`},
+		{name: "int8", field: zap.Int8("k", 8), want: `k=8`},
+		{name: "uint64", field: zap.Uint64("k", math.MaxUint64), want: `k=18446744073709551615`},
+		{name: "uintptr", field: zap.Uintptr("k", 0xff), want: `k=255`},
+		{name: "float64", field: zap.Float64("k", 1.5), want: `k=1.5`},
+		{name: "float32", field: zap.Float32("k", 0.25), want: `k=0.25`},
+		{name: "NaN", field: zap.Float64("k", math.NaN()), want: `k=NaN`},
+		{name: "Inf", field: zap.Float64("k", math.Inf(-1)), want: `k=-Inf`},
+		{name: "complex", field: zap.Complex128("k", complex(1, -2)), want: `k=1-2i`},
+		{name: "complex64", field: zap.Complex64("k", complex(1, 2)), want: `k=1+2i`},
+		{name: "duration", field: zap.Duration("k", time.Second), want: `k=1000000000`},
+		{name: "time", field: zap.Time("k", ts), want: `k=1727784000000000000`},
+		{name: "binary", field: zap.Binary("k", []byte("ab")), want: `k="YWI="`},
+		{name: "byte string", field: zap.ByteString("k", []byte("a b")), want: `k="a b"`},
+		{name: "error", field: zap.Error(errors.New("boom")), want: `error=boom`},
+		{name: "stringer", field: zap.Stringer("k", time.Second), want: `k=1s`},
+		{name: "reflect", field: zap.Reflect("k", map[string]int{"a": 1}), want: `k="{\"a\":1}"`},
+		{name: "object", field: zap.Object("user", testObject{name: "ace", count: 2}), want: `user.name=ace user.count=2`},
+		{name: "inline", field: zap.Inline(testObject{name: "ace", count: 2}), want: `name=ace count=2`},
+		{name: "int array", field: zap.Ints("k", []int{1, 2, 3}), want: `k=[1,2,3]`},
+		{name: "empty array", field: zap.Ints("k", nil), want: `k=[]`},
+		{name: "string array", field: zap.Strings("k", []string{"a", "b c", "d,e"}), want: `k="[a,\"b c\",\"d,e\"]"`},
+		{name: "object array", field: zap.Array("k", zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+			return enc.AppendObject(testObject{name: "ace", count: 1})
+		})), want: `k="[{name=ace,count=1}]"`},
+		{name: "nested array", field: zap.Array("k", zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+			enc.AppendBool(true)
+			return enc.AppendArray(zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+				enc.AppendInt(1)
+				enc.AppendFloat64(2.5)
+				return nil
+			}))
+		})), want: `k=[true,[1,2.5]]`},
+		{name: "failing array", field: zap.Array("k", failingArray{}), want: `k=[partial] kError=broken`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, encodeFields(t, tt.field))
+		})
+	}
+}
+
+func TestLogfmtEncoder_RoundTrip(t *testing.T) {
+	values := []string{
+		"plain",
+		"",
+		"with space",
+		`with "quotes"`,
+		"key=value",
+		"multi\nline\ttext",
+		`back\slash`,
+		"unicode ✓",
+		"é b",
+		"日本 a=b",
+		"日本\"",
+		"ctrl\x00\x1f\x7f",
+		"[brackets]",
+	}
+	for _, v := range values {
+		t.Run(v, func(t *testing.T) {
+			line := encodeFields(t, zap.String("k", v), zap.Int("n", 1))
+			assert.Equal(t, [][2]string{{"k", v}, {"n", "1"}}, decodeLogfmt(t, line))
+		})
+	}
+
+	for i := 0; i < 256; i++ {
+		v := string([]byte{'a', byte(i), 'b'})
+		line := encodeFields(t, zap.String("k", v))
+		want := v
+		if !utf8.ValidString(v) {
+			want = "a\ufffdb"
+		}
+		assert.Equal(t, [][2]string{{"k", want}}, decodeLogfmt(t, line), "byte %#x", i)
+	}
+}
+
+func TestNeedsQuote(t *testing.T) {
+	assert.False(t, needsQuote("日本", false), "Expected valid multi-byte text to stay unquoted")
+	assert.True(t, needsQuote("é b", false))
+	assert.True(t, needsQuote("日本 a=b", false))
+	assert.True(t, needsQuote("é\xff", false), "Expected invalid UTF-8 to be quoted")
+	assert.True(t, needsQuote("日本[x]", true))
+}
+
+func TestLogfmtEncoder_EncodeEntry(t *testing.T) {
+	enc := NewLogfmtEncoder(testEncoderConfig())
+	ent := zapcore.Entry{
+		Level:      zapcore.ErrorLevel,
+		Time:       time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC),
+		LoggerName: "db.pool",
+		Message:    "query failed",
+		Caller:     zapcore.NewEntryCaller(0, "/app/glog/db/pool.go", 42, true),
+		Stack:      "main.main\n\t/app/main.go:10",
+	}
+	ent.Caller.Function = "db.(*Pool).Query"
+
+	buf, err := enc.EncodeEntry(ent, []zapcore.Field{zap.Duration("elapsed", 1500*time.Millisecond)})
+	require.NoError(t, err)
+	defer buf.Free()
+
+	assert.Equal(t,
+		`level=error ts=2024-10-01T12:00:00.000Z logger=db.pool caller=db/pool.go:42 func=db.(*Pool).Query msg="query failed" elapsed=1.5s stacktrace="main.main\n\t/app/main.go:10"`+"\n",
+		buf.String(),
+	)
+	assert.Equal(t, [][2]string{
+		{"level", "error"},
+		{"ts", "2024-10-01T12:00:00.000Z"},
+		{"logger", "db.pool"},
+		{"caller", "db/pool.go:42"},
+		{"func", "db.(*Pool).Query"},
+		{"msg", "query failed"},
+		{"elapsed", "1.5s"},
+		{"stacktrace", "main.main\n\t/app/main.go:10"},
+	}, decodeLogfmt(t, strings.TrimSuffix(buf.String(), "\n")))
+}
+
+func TestLogfmtEncoder_EncoderConfig(t *testing.T) {
+	cfg := testEncoderConfig()
+	cfg.TimeKey = zapcore.OmitKey
+	cfg.CallerKey = zapcore.OmitKey
+	cfg.FunctionKey = zapcore.OmitKey
+	cfg.StacktraceKey = zapcore.OmitKey
+	cfg.LineEnding = "\r\n"
+	cfg.EncodeLevel = zapcore.CapitalLevelEncoder
+	cfg.EncodeName = func(name string, enc zapcore.PrimitiveArrayEncoder) {
+		enc.AppendString("[" + name + "]")
+	}
+	cfg.NewReflectedEncoder = func(w io.Writer) zapcore.ReflectedEncoder {
+		return reflectedFunc(func(v interface{}) error {
+			_, err := fmt.Fprintf(w, "%v", v)
+			return err
+		})
+	}
+
+	enc := NewLogfmtEncoder(cfg)
+	ent := zapcore.Entry{
+		Level:      zapcore.InfoLevel,
+		LoggerName: "app",
+		Message:    "hello",
+		Caller:     zapcore.NewEntryCaller(0, "main.go", 1, true),
+		Stack:      "ignored",
+	}
+	buf, err := enc.EncodeEntry(ent, []zapcore.Field{zap.Reflect("v", []int{1, 2})})
+	require.NoError(t, err)
+	defer buf.Free()
+
+	assert.Equal(t, "level=INFO logger=[app] msg=hello v=\"[1 2]\"\r\n", buf.String())
+}
+
+type reflectedFunc func(v interface{}) error
+
+func (f reflectedFunc) Encode(v interface{}) error {
+	return f(v)
+}
+
+func TestLogfmtEncoder_Namespace(t *testing.T) {
+	enc := NewLogfmtEncoder(zapcore.EncoderConfig{MessageKey: "msg", SkipLineEnding: true})
+	enc.AddString("service", "api")
+	enc.OpenNamespace("req")
+	enc.AddString("id", "123")
+
+	clone := enc.Clone()
+	clone.AddInt("attempt", 2)
+
+	buf, err := clone.EncodeEntry(zapcore.Entry{Message: "hi"}, []zapcore.Field{
+		zap.Namespace("user"),
+		zap.Object("addr", testObject{name: "home", count: 1}),
+		zap.String("name", "ace"),
+	})
+	require.NoError(t, err)
+	defer buf.Free()
+	assert.Equal(t, "msg=hi service=api req.id=123 req.attempt=2 req.user.addr.name=home req.user.addr.count=1 req.user.name=ace", buf.String())
+
+	buf2, err := enc.EncodeEntry(zapcore.Entry{Message: "hi"}, []zapcore.Field{zap.Int("n", 1)})
+	require.NoError(t, err)
+	defer buf2.Free()
+	assert.Equal(t, "msg=hi service=api req.id=123 req.n=1", buf2.String(), "Expected clone not to affect the original")
+}
+
+func TestLogfmtEncoder_Core(t *testing.T) {
+	var out strings.Builder
+	core := zapcore.NewCore(NewLogfmtEncoder(zapcore.EncoderConfig{MessageKey: "msg", LevelKey: "level", EncodeLevel: zapcore.LowercaseLevelEncoder}), zapcore.AddSync(&out), zapcore.DebugLevel)
+	logger := zap.New(core).With(zap.String("app", "glog"))
+	logger.Info("started", zap.Strings("tags", []string{"a", "b"}))
+
+	assert.Equal(t, "level=info msg=started app=glog tags=[a,b]\n", out.String())
+}
@@ -0,0 +1,161 @@
+package glog
+
+import (
+	"github.com/ace-zhaoy/glog/stacktrace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"reflect"
+	"runtime"
+	"sync"
+)
+
+const (
+	errorKey = "error"
+
+	// maxErrorDepth bounds the nesting of causes, in case of a cycle.
+	maxErrorDepth = 32
+)
+
+// Err is NamedErr with the "error" key.
+func Err(err error) Field {
+	return NamedErr(errorKey, err)
+}
+
+// NamedErr renders err as an object holding its message, the stack attached
+// to it if any, and its causes as given by Unwrap, recursively:
+//
+//	{"msg":"load: open a.yaml: no such file","stack":"...","causes":[{"msg":"open a.yaml: no such file","causes":[...]}]}
+//
+// An error has a stack if it has a Callers() []uintptr method, as errors from
+// the glog/errors package do, or a StackTrace() method returning a []uintptr
+// or []runtime.Frame, or a slice of another type of program counters, such as
+// the StackTrace of github.com/pkg/errors. A nil err is skipped.
+func NamedErr(key string, err error) Field {
+	if err == nil {
+		return Skip()
+	}
+	return Object(key, errorObject{err: err})
+}
+
+// Errors renders errs as an array of the objects of NamedErr, skipping nil errors.
+func Errors(key string, errs []error) Field {
+	return zap.Array(key, errorArray{errs: errs})
+}
+
+type errorObject struct {
+	err   error
+	depth int
+}
+
+func (e errorObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("msg", e.err.Error())
+	if stack := errorStack(e.err); stack != "" {
+		enc.AddString("stack", stack)
+	}
+	if e.depth < maxErrorDepth {
+		if causes := unwrapErrors(e.err); len(causes) > 0 {
+			return enc.AddArray("causes", errorArray{errs: causes, depth: e.depth + 1})
+		}
+	}
+	return nil
+}
+
+type errorArray struct {
+	errs  []error
+	depth int
+}
+
+func (a errorArray) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, err := range a.errs {
+		if err == nil {
+			continue
+		}
+		if e := enc.AppendObject(errorObject{err: err, depth: a.depth}); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+func unwrapErrors(err error) []error {
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		if cause := e.Unwrap(); cause != nil {
+			return []error{cause}
+		}
+	case interface{ Unwrap() []error }:
+		return e.Unwrap()
+	}
+	return nil
+}
+
+// errorStack formats the stack attached to err, if any.
+func errorStack(err error) string {
+	switch e := err.(type) {
+	case interface{ Callers() []uintptr }:
+		return formatPCs(e.Callers())
+	case interface{ StackTrace() []uintptr }:
+		return formatPCs(e.StackTrace())
+	case interface{ StackTrace() []runtime.Frame }:
+		frames := e.StackTrace()
+		if len(frames) == 0 {
+			return ""
+		}
+		formatter := stacktrace.GetFormatter()
+		defer formatter.Free()
+		for _, frame := range frames {
+			formatter.FormatFrame(frame)
+		}
+		return formatter.String()
+	}
+	return reflectedStack(err)
+}
+
+// stackTraceMethods caches the index of the StackTrace method returning a
+// slice of program counters of each error type, -1 if it has none, so that
+// reflection only looks up methods once per type.
+var stackTraceMethods sync.Map // reflect.Type -> int
+
+// reflectedStack formats the stack of StackTrace methods that return their own
+// slice types, such as the []Frame of github.com/pkg/errors, where Frame is a
+// program counter.
+func reflectedStack(err error) string {
+	v := reflect.ValueOf(err)
+	index, ok := stackTraceMethods.Load(v.Type())
+	if !ok {
+		index = stackTraceMethod(v.Type())
+		stackTraceMethods.Store(v.Type(), index)
+	}
+	if index.(int) < 0 {
+		return ""
+	}
+
+	trace := v.Method(index.(int)).Call(nil)[0]
+	pcs := make([]uintptr, trace.Len())
+	for i := range pcs {
+		pcs[i] = uintptr(trace.Index(i).Uint())
+	}
+	return formatPCs(pcs)
+}
+
+func stackTraceMethod(t reflect.Type) int {
+	m, ok := t.MethodByName("StackTrace")
+	// The receiver is the first input.
+	if !ok || m.Type.NumIn() != 1 || m.Type.NumOut() != 1 {
+		return -1
+	}
+	if out := m.Type.Out(0); out.Kind() != reflect.Slice || out.Elem().Kind() != reflect.Uintptr {
+		return -1
+	}
+	return m.Index
+}
+
+func formatPCs(pcs []uintptr) string {
+	if len(pcs) == 0 {
+		return ""
+	}
+	stack := stacktrace.FromPCs(pcs)
+	defer stack.Free()
+
+	return stack.String()
+}
@@ -0,0 +1,154 @@
+package glog
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ace-zhaoy/glog/stacktrace"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+type joinedError struct {
+	errs []error
+}
+
+func (e joinedError) Error() string {
+	return "joined"
+}
+
+func (e joinedError) Unwrap() []error {
+	return e.errs
+}
+
+// pcFrame and pcStackError mimic the errors of github.com/pkg/errors.
+type pcFrame uintptr
+
+type pcStackError struct {
+	pcs []uintptr
+}
+
+func (e pcStackError) Error() string {
+	return "pc stack"
+}
+
+func (e pcStackError) StackTrace() []pcFrame {
+	frames := make([]pcFrame, len(e.pcs))
+	for i, pc := range e.pcs {
+		frames[i] = pcFrame(pc)
+	}
+	return frames
+}
+
+type uintptrStackError struct {
+	pcs []uintptr
+}
+
+func (e uintptrStackError) Error() string {
+	return "uintptr stack"
+}
+
+func (e uintptrStackError) StackTrace() []uintptr {
+	return e.pcs
+}
+
+type stringStackError struct{}
+
+func (stringStackError) Error() string {
+	return "string stack"
+}
+
+func (stringStackError) StackTrace() []string {
+	return []string{"main.main"}
+}
+
+type frameStackError struct{}
+
+func (frameStackError) Error() string {
+	return "frame stack"
+}
+
+func (frameStackError) StackTrace() []runtime.Frame {
+	return []runtime.Frame{{Function: "pkg.f", File: "/src/pkg/f.go", Line: 12}}
+}
+
+type callersError struct {
+	pcs []uintptr
+}
+
+func (e callersError) Error() string {
+	return "callers"
+}
+
+func (e callersError) Callers() []uintptr {
+	return e.pcs
+}
+
+func encodeField(f Field) map[string]any {
+	enc := zapcore.NewMapObjectEncoder()
+	f.AddTo(enc)
+	return enc.Fields
+}
+
+func TestErr(t *testing.T) {
+	base := errors.New("no such file")
+	err := fmt.Errorf("load: %w", fmt.Errorf("open a.yaml: %w", base))
+
+	assert.Equal(t, map[string]any{
+		"error": map[string]any{
+			"msg": "load: open a.yaml: no such file",
+			"causes": []any{map[string]any{
+				"msg": "open a.yaml: no such file",
+				"causes": []any{map[string]any{
+					"msg": "no such file",
+				}},
+			}},
+		},
+	}, encodeField(Err(err)))
+
+	assert.Equal(t, Skip(), Err(nil), "Expected a nil error to be skipped")
+}
+
+func TestNamedErr_join(t *testing.T) {
+	err := joinedError{errs: []error{errors.New("a"), nil, errors.New("b")}}
+	assert.Equal(t, map[string]any{
+		"cause": map[string]any{
+			"msg": "joined",
+			"causes": []any{
+				map[string]any{"msg": "a"},
+				map[string]any{"msg": "b"},
+			},
+		},
+	}, encodeField(NamedErr("cause", err)))
+}
+
+func TestNamedErr_stack(t *testing.T) {
+	pcs := stacktrace.Callers(0)
+
+	for _, err := range []error{callersError{pcs: pcs}, pcStackError{pcs: pcs}, uintptrStackError{pcs: pcs}} {
+		obj := encodeField(Err(err))["error"].(map[string]any)
+		stack, _ := obj["stack"].(string)
+		assert.True(t, strings.HasPrefix(stack, "github.com/ace-zhaoy/glog.TestNamedErr_stack\n"), "Unexpected stack for %T: %s", err, stack)
+		assert.Contains(t, stack, "error_test.go:")
+	}
+
+	obj := encodeField(Err(fmt.Errorf("wrapped: %w", frameStackError{})))["error"].(map[string]any)
+	assert.NotContains(t, obj, "stack")
+	assert.Equal(t, []any{map[string]any{
+		"msg":   "frame stack",
+		"stack": "pkg.f\n\t/src/pkg/f.go:12",
+	}}, obj["causes"], "Expected stacks of causes to be rendered")
+
+	assert.Equal(t, map[string]any{"msg": "string stack"}, encodeField(Err(stringStackError{}))["error"], "Expected other stack types to be ignored")
+}
+
+func TestErrors(t *testing.T) {
+	assert.Equal(t, map[string]any{
+		"errors": []any{
+			map[string]any{"msg": "a"},
+			map[string]any{"msg": "b"},
+		},
+	}, encodeField(Errors("errors", []error{errors.New("a"), nil, errors.New("b")})))
+}
@@ -0,0 +1,74 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ace-zhaoy/glog/stacktrace"
+)
+
+type stackError struct {
+	msg   string
+	cause error
+	pcs   []uintptr
+}
+
+func (e *stackError) Error() string {
+	return e.msg
+}
+
+func (e *stackError) Unwrap() error {
+	return e.cause
+}
+
+// Callers returns the program counters of the stack where the error was created.
+func (e *stackError) Callers() []uintptr {
+	return e.pcs
+}
+
+// New returns an error with msg and the stack of its caller.
+func New(msg string) error {
+	return &stackError{msg: msg, pcs: stacktrace.Callers(1)}
+}
+
+// Errorf formats the message like fmt.Errorf and returns an error with it and
+// the stack of its caller. The errors wrapped with %w are its causes; several
+// %w verbs need Go 1.20.
+func Errorf(format string, args ...any) error {
+	err := fmt.Errorf(format, args...)
+	pcs := stacktrace.Callers(1)
+	if e, ok := withCauses(err, pcs); ok {
+		return e
+	}
+	return &stackError{msg: err.Error(), cause: errors.Unwrap(err), pcs: pcs}
+}
+
+// Wrap returns an error with the message "msg: err", err as its cause and the
+// stack of its caller. It returns nil if err is nil.
+func Wrap(err error, msg string) error {
+	if err == nil {
+		return nil
+	}
+	return &stackError{msg: msg + ": " + err.Error(), cause: err, pcs: stacktrace.Callers(1)}
+}
+
+// WithStack returns an error with the message of err, err as its cause and
+// the stack of its caller. It returns nil if err is nil.
+func WithStack(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &stackError{msg: err.Error(), cause: err, pcs: stacktrace.Callers(1)}
+}
+
+func Is(err, target error) bool {
+	return errors.Is(err, target)
+}
+
+func As(err error, target any) bool {
+	return errors.As(err, target)
+}
+
+func Unwrap(err error) error {
+	return errors.Unwrap(err)
+}
@@ -0,0 +1,52 @@
+package errors
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ace-zhaoy/glog/stacktrace"
+	"github.com/stretchr/testify/assert"
+)
+
+func stackOf(err error) string {
+	stack := stacktrace.FromPCs(err.(interface{ Callers() []uintptr }).Callers())
+	defer stack.Free()
+	return stack.String()
+}
+
+func TestNew(t *testing.T) {
+	err := New("boom")
+	assert.Equal(t, "boom", err.Error())
+	assert.Nil(t, Unwrap(err))
+	assert.True(t, strings.HasPrefix(stackOf(err), "github.com/ace-zhaoy/glog/errors.TestNew\n"), "Expected the stack to start at the caller")
+}
+
+func TestErrorf(t *testing.T) {
+	err := Errorf("read %s: %w", "a.yaml", io.EOF)
+	assert.Equal(t, "read a.yaml: EOF", err.Error())
+	assert.Equal(t, io.EOF, Unwrap(err))
+	assert.True(t, Is(err, io.EOF))
+	assert.True(t, strings.HasPrefix(stackOf(err), "github.com/ace-zhaoy/glog/errors.TestErrorf\n"))
+
+	assert.Nil(t, Unwrap(Errorf("no cause %d", 1)))
+}
+
+func TestWrap(t *testing.T) {
+	assert.Nil(t, Wrap(nil, "msg"))
+	assert.Nil(t, WithStack(nil))
+
+	err := Wrap(io.EOF, "read")
+	assert.Equal(t, "read: EOF", err.Error())
+	assert.True(t, Is(err, io.EOF))
+	assert.True(t, strings.HasPrefix(stackOf(err), "github.com/ace-zhaoy/glog/errors.TestWrap\n"))
+
+	err = WithStack(io.EOF)
+	assert.Equal(t, "EOF", err.Error())
+	assert.Equal(t, io.EOF, Unwrap(err))
+
+	var target *stackError
+	assert.True(t, As(Wrap(err, "outer"), &target))
+	assert.True(t, errors.Is(target, io.EOF))
+}
@@ -0,0 +1,33 @@
+//go:build go1.20
+
+package errors
+
+// joinedStackError is a stackError with several causes.
+type joinedStackError struct {
+	msg    string
+	causes []error
+	pcs    []uintptr
+}
+
+func (e *joinedStackError) Error() string {
+	return e.msg
+}
+
+func (e *joinedStackError) Unwrap() []error {
+	return e.causes
+}
+
+// Callers returns the program counters of the stack where the error was created.
+func (e *joinedStackError) Callers() []uintptr {
+	return e.pcs
+}
+
+// withCauses returns an error with the message of err, the errors it wraps as
+// causes and pcs as its stack, if err wraps several errors.
+func withCauses(err error, pcs []uintptr) (error, bool) {
+	w, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return nil, false
+	}
+	return &joinedStackError{msg: err.Error(), causes: w.Unwrap(), pcs: pcs}, true
+}
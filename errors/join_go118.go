@@ -0,0 +1,8 @@
+//go:build !go1.20
+
+package errors
+
+// withCauses reports false, errors wrap a single error before Go 1.20.
+func withCauses(err error, pcs []uintptr) (error, bool) {
+	return nil, false
+}
@@ -0,0 +1,19 @@
+//go:build go1.20
+
+package errors
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorf_join(t *testing.T) {
+	err := Errorf("read: %w, close: %w", io.EOF, io.ErrClosedPipe)
+	assert.Equal(t, "read: EOF, close: io: read/write on closed pipe", err.Error())
+	assert.Equal(t, []error{io.EOF, io.ErrClosedPipe}, err.(interface{ Unwrap() []error }).Unwrap(), "Expected the wrapped errors as causes")
+	assert.True(t, Is(err, io.ErrClosedPipe))
+	assert.True(t, strings.HasPrefix(stackOf(err), "github.com/ace-zhaoy/glog/errors.TestErrorf_join\n"))
+}
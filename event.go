@@ -0,0 +1,227 @@
+package glog
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// maxPooledEventFields bounds the buffers kept by pooled events, so a single
+// large entry does not pin memory.
+const maxPooledEventFields = 64
+
+var eventPool = sync.Pool{
+	New: func() any {
+		return &Event{fields: make([]Field, 0, 8)}
+	},
+}
+
+// Event is an entry built with chained calls and written by Msg, Msgf or
+// Send, which also release it, so it must not be used afterwards:
+//
+//	l.InfoEvent().Str("user_id", id).Int("attempt", 3).Err(err).Ctx(ctx).Msg("login failed")
+//
+// Events are pooled and primitive fields do not allocate. A disabled level
+// returns a nil Event, whose methods do nothing.
+type Event struct {
+	logger *Logger
+	level  Level
+	ctx    context.Context
+	fields []Field
+	record Record
+}
+
+// Event starts an entry at lvl. It returns nil if lvl is disabled.
+func (l *Logger) Event(lvl Level) *Event {
+	if lvl < LevelDPanic && !l.Enabled(lvl) {
+		return nil
+	}
+	e := eventPool.Get().(*Event)
+	e.logger = l
+	e.level = lvl
+	return e
+}
+
+func (l *Logger) DebugEvent() *Event {
+	return l.Event(LevelDebug)
+}
+
+func (l *Logger) InfoEvent() *Event {
+	return l.Event(LevelInfo)
+}
+
+func (l *Logger) WarnEvent() *Event {
+	return l.Event(LevelWarn)
+}
+
+func (l *Logger) ErrorEvent() *Event {
+	return l.Event(LevelError)
+}
+
+// DPanicEvent starts an entry at LevelDPanic. In development mode the logger
+// panics once it is written.
+func (l *Logger) DPanicEvent() *Event {
+	return l.Event(LevelDPanic)
+}
+
+// PanicEvent starts an entry at LevelPanic. The logger panics once it is written.
+func (l *Logger) PanicEvent() *Event {
+	return l.Event(LevelPanic)
+}
+
+// FatalEvent starts an entry at LevelFatal. The logger exits once it is written.
+func (l *Logger) FatalEvent() *Event {
+	return l.Event(LevelFatal)
+}
+
+func putEvent(e *Event) {
+	if cap(e.fields) > maxPooledEventFields || cap(e.record.fields) > maxPooledEventFields {
+		return
+	}
+	e.logger = nil
+	e.ctx = nil
+	e.fields = clearFields(e.fields)
+	e.record.fields = clearFields(e.record.fields)
+	eventPool.Put(e)
+}
+
+// clearFields empties fields, dropping the values they reference.
+func clearFields(fields []Field) []Field {
+	for i := range fields {
+		fields[i] = Field{}
+	}
+	return fields[:0]
+}
+
+// Ctx sets the context passed to the context handlers of the logger.
+func (e *Event) Ctx(ctx context.Context) *Event {
+	if e != nil {
+		e.ctx = ctx
+	}
+	return e
+}
+
+func (e *Event) Fields(fields ...Field) *Event {
+	if e != nil {
+		e.fields = append(e.fields, fields...)
+	}
+	return e
+}
+
+func (e *Event) Str(key string, val string) *Event {
+	if e != nil {
+		e.fields = append(e.fields, String(key, val))
+	}
+	return e
+}
+
+func (e *Event) Int(key string, val int) *Event {
+	if e != nil {
+		e.fields = append(e.fields, Int(key, val))
+	}
+	return e
+}
+
+func (e *Event) Int64(key string, val int64) *Event {
+	if e != nil {
+		e.fields = append(e.fields, Int64(key, val))
+	}
+	return e
+}
+
+func (e *Event) Uint64(key string, val uint64) *Event {
+	if e != nil {
+		e.fields = append(e.fields, Uint64(key, val))
+	}
+	return e
+}
+
+func (e *Event) Float64(key string, val float64) *Event {
+	if e != nil {
+		e.fields = append(e.fields, Float64(key, val))
+	}
+	return e
+}
+
+func (e *Event) Bool(key string, val bool) *Event {
+	if e != nil {
+		e.fields = append(e.fields, Bool(key, val))
+	}
+	return e
+}
+
+func (e *Event) Dur(key string, val time.Duration) *Event {
+	if e != nil {
+		e.fields = append(e.fields, Duration(key, val))
+	}
+	return e
+}
+
+func (e *Event) Time(key string, val time.Time) *Event {
+	if e != nil {
+		e.fields = append(e.fields, Time(key, val))
+	}
+	return e
+}
+
+// Err adds err as Err does. A nil err adds nothing.
+func (e *Event) Err(err error) *Event {
+	if e != nil && err != nil {
+		e.fields = append(e.fields, Err(err))
+	}
+	return e
+}
+
+func (e *Event) Any(key string, val any) *Event {
+	if e != nil {
+		e.fields = append(e.fields, Any(key, val))
+	}
+	return e
+}
+
+// Msg writes the entry with msg and releases the Event.
+func (e *Event) Msg(msg string) {
+	if e == nil {
+		return
+	}
+	e.write(msg)
+}
+
+// Msgf writes the entry with the message formatted by fmt.Sprintf and
+// releases the Event.
+func (e *Event) Msgf(format string, args ...any) {
+	if e == nil {
+		return
+	}
+	e.write(fmt.Sprintf(format, args...))
+}
+
+// Send writes the entry with an empty message and releases the Event.
+func (e *Event) Send() {
+	if e == nil {
+		return
+	}
+	e.write("")
+}
+
+// write must be called directly by Msg, Msgf or Send, so the caller skip of
+// check matches that of Logger.log.
+func (e *Event) write(msg string) {
+	l, lvl := e.logger, e.level
+	defer l.terminate(lvl, msg)
+	defer putEvent(e)
+
+	ce := l.check(lvl, msg)
+	if ce == nil {
+		return
+	}
+
+	e.record.fields = e.record.fields[:0]
+	if e.ctx != nil {
+		l.handleContext(e.ctx, &e.record)
+	}
+	e.record.AddFields(e.fields...)
+
+	ce.Write(e.record.fields...)
+}
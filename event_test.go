@@ -0,0 +1,115 @@
+package glog
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLogger_Event(t *testing.T) {
+	core := &mockCore{enabled: true}
+	logger := NewLogger(core, AddCaller(), WithContextHandlers(BuildContextHandler("request_id")))
+
+	ctx := context.WithValue(context.Background(), "request_id", "abc")
+	err := errors.New("boom")
+	logger.InfoEvent().
+		Str("user", "bob").
+		Int("attempt", 3).
+		Bool("ok", false).
+		Dur("took", time.Second).
+		Err(err).
+		Err(nil).
+		Fields(String("extra", "x")).
+		Ctx(ctx).
+		Msg("login failed")
+
+	if assert.Len(t, core.entries, 1) {
+		assert.Equal(t, LevelInfo, core.entries[0].Level)
+		assert.Equal(t, "login failed", core.entries[0].Message)
+		assert.Equal(t, "event_test.go", filepath.Base(core.entries[0].Caller.File), "Expected the caller of Msg")
+	}
+	assert.Equal(t, []Field{
+		Any("request_id", "abc"),
+		String("user", "bob"),
+		Int("attempt", 3),
+		Bool("ok", false),
+		Duration("took", time.Second),
+		Err(err),
+		String("extra", "x"),
+	}, core.fields, "Expected context handler fields before event fields")
+
+	core.fields = nil
+	logger.WarnEvent().Int("n", 1).Msgf("retry %d of %d", 1, 3)
+	logger.ErrorEvent().Send()
+	if assert.Len(t, core.entries, 3) {
+		assert.Equal(t, "retry 1 of 3", core.entries[1].Message)
+		assert.Equal(t, LevelError, core.entries[2].Level)
+		assert.Equal(t, "", core.entries[2].Message)
+		assert.Equal(t, "event_test.go", filepath.Base(core.entries[1].Caller.File), "Expected the caller of Msgf")
+	}
+	assert.Equal(t, []Field{Int("n", 1)}, core.fields, "Expected pooled events to start empty")
+}
+
+func TestLogger_Event_disabled(t *testing.T) {
+	core := &mockCore{enabled: true}
+	logger := NewLogger(core, WithLevel(NewAtomicLevelAt(LevelInfo)))
+
+	e := logger.DebugEvent()
+	assert.Nil(t, e, "Expected a nil event for a disabled level")
+	e.Str("k", "v").Int("n", 1).Ctx(context.Background()).Msg("ignored")
+	e.Msgf("ignored %d", 1)
+	e.Send()
+	assert.Empty(t, core.entries)
+}
+
+func TestLogger_Event_terminate(t *testing.T) {
+	core := &mockCore{enabled: true}
+	exitCode := -1
+	logger := NewLogger(core, WithExitFunc(func(code int) { exitCode = code }))
+
+	assert.PanicsWithValue(t, "panic msg", func() {
+		logger.PanicEvent().Str("k", "v").Msg("panic msg")
+	})
+	logger.FatalEvent().Msg("fatal msg")
+	assert.Equal(t, 1, exitCode)
+	logger.DPanicEvent().Msg("dpanic msg")
+	assert.Len(t, core.entries, 3, "Expected DPanic not to panic outside development mode")
+}
+
+func newBenchmarkLogger() *Logger {
+	encoder := zapcore.NewJSONEncoder(zapcore.EncoderConfig{MessageKey: "msg", LevelKey: "level", EncodeLevel: zapcore.LowercaseLevelEncoder})
+	core := zapcore.NewCore(encoder, zapcore.AddSync(io.Discard), LevelDebug)
+	return NewLogger(core, WithLevel(NewAtomicLevelAt(LevelInfo)))
+}
+
+func BenchmarkEvent_disabled(b *testing.B) {
+	logger := newBenchmarkLogger()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.DebugEvent().Str("user", "bob").Int("attempt", 3).Msg("login failed")
+	}
+}
+
+func BenchmarkEvent_enabled(b *testing.B) {
+	logger := newBenchmarkLogger()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.InfoEvent().Str("user", "bob").Int("attempt", 3).Bool("ok", false).Msg("login failed")
+	}
+}
+
+func BenchmarkLogger_Info(b *testing.B) {
+	logger := newBenchmarkLogger()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.Info("login failed", "user", "bob", "attempt", 3, "ok", false)
+	}
+}
@@ -0,0 +1,141 @@
+package glogtest
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ace-zhaoy/glog"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// Entry is an entry recorded by an Observer. Fields holds the fields of the
+// entry, including those added by context handlers and With.
+type Entry = observer.LoggedEntry
+
+// Observer records the entries written by a logger from NewObserved.
+// It is safe for concurrent use.
+type Observer struct {
+	logs *observer.ObservedLogs
+}
+
+// NewObserved returns a logger that records entries at every level and the
+// Observer holding them.
+func NewObserved(opts ...glog.Option) (*glog.Logger, *Observer) {
+	return NewObservedAt(glog.LevelDebug, opts...)
+}
+
+// NewObservedAt returns a logger that records entries enabled by lvl and the
+// Observer holding them.
+func NewObservedAt(lvl glog.LevelEnabler, opts ...glog.Option) (*glog.Logger, *Observer) {
+	core, logs := observer.New(lvl)
+	return glog.NewLogger(core, opts...), &Observer{logs: logs}
+}
+
+func (o *Observer) Len() int {
+	return o.logs.Len()
+}
+
+// All returns a copy of the recorded entries.
+func (o *Observer) All() []Entry {
+	return o.logs.All()
+}
+
+// TakeAll returns the recorded entries and clears the Observer.
+func (o *Observer) TakeAll() []Entry {
+	return o.logs.TakeAll()
+}
+
+// Messages returns the messages of the recorded entries.
+func (o *Observer) Messages() []string {
+	entries := o.logs.All()
+	msgs := make([]string, 0, len(entries))
+	for _, e := range entries {
+		msgs = append(msgs, e.Message)
+	}
+	return msgs
+}
+
+// Filter returns an Observer with a copy of the entries f returns true for.
+func (o *Observer) Filter(f func(Entry) bool) *Observer {
+	return &Observer{logs: o.logs.Filter(f)}
+}
+
+func (o *Observer) FilterLevel(lvl glog.Level) *Observer {
+	return &Observer{logs: o.logs.FilterLevelExact(lvl)}
+}
+
+// FilterMinLevel keeps the entries at lvl or above.
+func (o *Observer) FilterMinLevel(lvl glog.Level) *Observer {
+	return o.Filter(func(e Entry) bool {
+		return e.Level >= lvl
+	})
+}
+
+func (o *Observer) FilterMessage(msg string) *Observer {
+	return &Observer{logs: o.logs.FilterMessage(msg)}
+}
+
+// FilterMessageContains keeps the entries whose message contains s.
+func (o *Observer) FilterMessageContains(s string) *Observer {
+	return o.Filter(func(e Entry) bool {
+		return strings.Contains(e.Message, s)
+	})
+}
+
+// FilterField keeps the entries that have a field equal to f.
+func (o *Observer) FilterField(f glog.Field) *Observer {
+	return &Observer{logs: o.logs.FilterField(f)}
+}
+
+// FilterFieldKey keeps the entries that have a field named key.
+func (o *Observer) FilterFieldKey(key string) *Observer {
+	return &Observer{logs: o.logs.FilterFieldKey(key)}
+}
+
+// FilterFieldValue keeps the entries whose field named key encodes to the
+// same value as glog.Any(key, value), so FilterFieldValue("n", 1) matches
+// glog.Int64("n", 1).
+func (o *Observer) FilterFieldValue(key string, value any) *Observer {
+	enc := zapcore.NewMapObjectEncoder()
+	glog.Any(key, value).AddTo(enc)
+	want := enc.Fields[key]
+	return o.Filter(func(e Entry) bool {
+		got, ok := e.ContextMap()[key]
+		return ok && reflect.DeepEqual(got, want)
+	})
+}
+
+// testingWriter writes every entry to the log of a test.
+type testingWriter struct {
+	t testing.TB
+}
+
+func (w testingWriter) Write(p []byte) (int, error) {
+	w.t.Helper()
+	w.t.Log(strings.TrimSuffix(string(p), "\n"))
+	return len(p), nil
+}
+
+func (w testingWriter) Sync() error {
+	return nil
+}
+
+// NewLogger returns a logger that writes every entry through t.Log, so the
+// output is attributed to the test and only shown when it fails or with -v.
+func NewLogger(t testing.TB, opts ...glog.Option) *glog.Logger {
+	encoder := zapcore.NewConsoleEncoder(zapcore.EncoderConfig{
+		LevelKey:       "level",
+		NameKey:        "logger",
+		CallerKey:      "caller",
+		MessageKey:     "msg",
+		StacktraceKey:  "stacktrace",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    zapcore.CapitalLevelEncoder,
+		EncodeDuration: zapcore.StringDurationEncoder,
+		EncodeCaller:   zapcore.ShortCallerEncoder,
+	})
+	core := zapcore.NewCore(encoder, testingWriter{t: t}, glog.LevelDebug)
+	return glog.NewLogger(core, opts...)
+}
@@ -0,0 +1,77 @@
+package glogtest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ace-zhaoy/glog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewObserved(t *testing.T) {
+	logger, logs := NewObserved(glog.WithContextHandlers(glog.BuildContextHandler("request_id")))
+
+	ctx := context.WithValue(context.Background(), "request_id", "abc")
+	logger.Debug("debug message")
+	logger.InfoContext(ctx, "user created", "user_id", 42)
+	logger.With("component", "db").Warn("slow query", "took_ms", 120)
+	logger.Error("query failed", glog.String("table", "users"))
+
+	assert.Equal(t, 4, logs.Len())
+	assert.Equal(t, []string{"debug message", "user created", "slow query", "query failed"}, logs.Messages())
+
+	assert.Equal(t, []string{"query failed"}, logs.FilterLevel(glog.LevelError).Messages())
+	assert.Equal(t, []string{"slow query", "query failed"}, logs.FilterMinLevel(glog.LevelWarn).Messages())
+	assert.Equal(t, []string{"user created"}, logs.FilterMessage("user created").Messages())
+	assert.Equal(t, []string{"slow query", "query failed"}, logs.FilterMessageContains("query").Messages())
+	assert.Equal(t, []string{"query failed"}, logs.FilterField(glog.String("table", "users")).Messages())
+	assert.Equal(t, []string{"slow query"}, logs.FilterFieldKey("component").Messages(), "Expected With fields to be observed")
+	assert.Equal(t, []string{"user created"}, logs.FilterFieldValue("user_id", 42).Messages())
+	assert.Empty(t, logs.FilterFieldValue("user_id", 43).Messages())
+
+	entries := logs.FilterFieldValue("request_id", "abc").All()
+	if assert.Len(t, entries, 1, "Expected context handler fields to be observed") {
+		assert.Equal(t, map[string]any{"request_id": "abc", "user_id": int64(42)}, entries[0].ContextMap())
+	}
+
+	assert.Len(t, logs.FilterLevel(glog.LevelInfo).Filter(func(e Entry) bool {
+		return e.ContextMap()["user_id"] == int64(42)
+	}).All(), 1, "Expected filters to chain")
+
+	assert.Len(t, logs.TakeAll(), 4)
+	assert.Equal(t, 0, logs.Len(), "Expected TakeAll to clear the observer")
+}
+
+func TestNewObservedAt(t *testing.T) {
+	logger, logs := NewObservedAt(glog.LevelWarn)
+	logger.Info("info")
+	logger.Warn("warn")
+	assert.Equal(t, []string{"warn"}, logs.Messages())
+}
+
+type recordingTB struct {
+	testing.TB
+	logs []string
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Log(args ...any) {
+	r.logs = append(r.logs, fmt.Sprint(args...))
+}
+
+func TestNewLogger(t *testing.T) {
+	tb := &recordingTB{TB: t}
+	logger := NewLogger(tb).Named("svc")
+	logger.Info("hello", "user_id", 42)
+	logger.Debug("details")
+
+	if assert.Len(t, tb.logs, 2) {
+		assert.Equal(t, "INFO\tsvc\thello\t{\"user_id\": 42}", tb.logs[0])
+		assert.True(t, strings.HasPrefix(tb.logs[1], "DEBUG\tsvc\tdetails"), "Unexpected log: %q", tb.logs[1])
+	}
+
+	NewLogger(t).Info("written through t.Log")
+}
@@ -0,0 +1,304 @@
+package grpclog
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ace-zhaoy/glog"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	DefaultMessage = "grpc call"
+
+	healthService = "grpc.health.v1.Health"
+)
+
+// Decider reports whether the call to fullMethod is logged.
+type Decider func(fullMethod string) bool
+
+// DefaultDecider logs every call except health checks.
+func DefaultDecider(fullMethod string) bool {
+	return path.Dir(fullMethod) != "/"+healthService
+}
+
+var (
+	metadataKeysMu sync.Mutex
+	metadataKeys   = map[string]*glog.ContextKey[string]{}
+)
+
+// MetadataKey returns the context key under which the interceptors store the
+// incoming value of the metadata key name and read its outgoing value. Use it
+// with glog.BuildTypedContextHandler, or list name in glog.Config.ContextFields:
+// the key is registered with glog.RegisterContextKey when first returned,
+// unless another key already has that name.
+func MetadataKey(name string) *glog.ContextKey[string] {
+	name = strings.ToLower(name)
+	metadataKeysMu.Lock()
+	defer metadataKeysMu.Unlock()
+
+	if key, ok := metadataKeys[name]; ok {
+		return key
+	}
+	key := glog.NewContextKey[string](name)
+	_ = glog.RegisterContextKey(key)
+	metadataKeys[name] = key
+	return key
+}
+
+type config struct {
+	decider      Decider
+	metadataKeys []*glog.ContextKey[string]
+	payloads     bool
+	levelFunc    func(code codes.Code) glog.Level
+	message      string
+}
+
+type Option func(*config)
+
+func WithDecider(d Decider) Option {
+	return func(c *config) {
+		c.decider = d
+	}
+}
+
+// WithMetadataKeys propagates the metadata keys between calls and contexts.
+// Server interceptors store incoming values in the context under MetadataKey,
+// where context handlers pick them up. Client interceptors send the values
+// stored under MetadataKey as outgoing metadata.
+func WithMetadataKeys(keys ...string) Option {
+	return func(c *config) {
+		for _, key := range keys {
+			c.metadataKeys = append(c.metadataKeys, MetadataKey(key))
+		}
+	}
+}
+
+// WithPayloads logs the type and size of request and response messages.
+func WithPayloads(enabled bool) Option {
+	return func(c *config) {
+		c.payloads = enabled
+	}
+}
+
+// WithLevelFunc sets the function that picks the log level from the status code.
+func WithLevelFunc(f func(code codes.Code) glog.Level) Option {
+	return func(c *config) {
+		c.levelFunc = f
+	}
+}
+
+func WithMessage(msg string) Option {
+	return func(c *config) {
+		c.message = msg
+	}
+}
+
+// DefaultLevel logs successful calls at info level, errors caused by the
+// caller at warn and other errors at error level.
+func DefaultLevel(code codes.Code) glog.Level {
+	switch code {
+	case codes.OK:
+		return glog.LevelInfo
+	case codes.Canceled, codes.InvalidArgument, codes.NotFound, codes.AlreadyExists,
+		codes.PermissionDenied, codes.Unauthenticated, codes.FailedPrecondition,
+		codes.OutOfRange, codes.ResourceExhausted, codes.Aborted:
+		return glog.LevelWarn
+	default:
+		return glog.LevelError
+	}
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{
+		decider:   DefaultDecider,
+		levelFunc: DefaultLevel,
+		message:   DefaultMessage,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// incomingContext stores the configured incoming metadata values in ctx.
+func (c *config) incomingContext(ctx context.Context) context.Context {
+	if len(c.metadataKeys) == 0 {
+		return ctx
+	}
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+	for _, key := range c.metadataKeys {
+		if values := md.Get(key.Name()); len(values) > 0 {
+			ctx = key.WithValue(ctx, values[0])
+		}
+	}
+	return ctx
+}
+
+// outgoingContext adds the configured context values to the outgoing metadata.
+func (c *config) outgoingContext(ctx context.Context) context.Context {
+	for _, key := range c.metadataKeys {
+		if v, ok := key.Value(ctx); ok && v != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, key.Name(), v)
+		}
+	}
+	return ctx
+}
+
+func (c *config) log(ctx context.Context, logger *glog.Logger, kind, fullMethod string, start time.Time, err error, extra ...any) {
+	code := status.Code(err)
+	fields := make([]any, 0, 8+len(extra))
+	fields = append(fields,
+		glog.String("grpc.kind", kind),
+		glog.String("grpc.method", fullMethod),
+		glog.String("grpc.code", code.String()),
+		glog.Duration("grpc.duration", time.Since(start)),
+	)
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		fields = append(fields, glog.String("grpc.peer", p.Addr.String()))
+	}
+	if err != nil {
+		fields = append(fields, glog.String("grpc.error", status.Convert(err).Message()))
+	}
+	fields = append(fields, extra...)
+	logger.LogContext(ctx, c.levelFunc(code), c.message, fields...)
+}
+
+// payload summarises a message by its type and size.
+type payload struct {
+	msg any
+}
+
+func (p payload) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	if m, ok := p.msg.(proto.Message); ok {
+		enc.AddString("type", string(m.ProtoReflect().Descriptor().FullName()))
+		enc.AddInt("size", proto.Size(m))
+		return nil
+	}
+	enc.AddString("type", fmt.Sprintf("%T", p.msg))
+	return nil
+}
+
+// UnaryServerInterceptor logs unary calls handled by the server and stores a
+// logger carrying the method in the context for glog.FromContext.
+func UnaryServerInterceptor(logger *glog.Logger, opts ...Option) grpc.UnaryServerInterceptor {
+	c := newConfig(opts)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx = c.incomingContext(ctx)
+		ctx = glog.NewContext(ctx, logger.With(glog.String("grpc.method", info.FullMethod)))
+		if !c.decider(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		var extra []any
+		if c.payloads {
+			extra = append(extra, glog.Object("grpc.request", payload{req}))
+			if err == nil {
+				extra = append(extra, glog.Object("grpc.response", payload{resp}))
+			}
+		}
+		c.log(ctx, logger, "server", info.FullMethod, start, err, extra...)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor logs streaming calls handled by the server and
+// stores a logger carrying the method in the stream context.
+func StreamServerInterceptor(logger *glog.Logger, opts ...Option) grpc.StreamServerInterceptor {
+	c := newConfig(opts)
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := c.incomingContext(ss.Context())
+		ctx = glog.NewContext(ctx, logger.With(glog.String("grpc.method", info.FullMethod)))
+		if !c.decider(info.FullMethod) {
+			return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		}
+
+		start := time.Now()
+		stream := &serverStream{ServerStream: ss, ctx: ctx}
+		err := handler(srv, stream)
+		c.log(ctx, logger, "server", info.FullMethod, start, err,
+			glog.Int64("grpc.msgs_sent", atomic.LoadInt64(&stream.sent)),
+			glog.Int64("grpc.msgs_received", atomic.LoadInt64(&stream.received)),
+		)
+		return err
+	}
+}
+
+// UnaryClientInterceptor logs unary calls made by the client.
+func UnaryClientInterceptor(logger *glog.Logger, opts ...Option) grpc.UnaryClientInterceptor {
+	c := newConfig(opts)
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		ctx = c.outgoingContext(ctx)
+		if !c.decider(method) {
+			return invoker(ctx, method, req, reply, cc, callOpts...)
+		}
+
+		start := time.Now()
+		var p peer.Peer
+		err := invoker(ctx, method, req, reply, cc, append(callOpts, grpc.Peer(&p))...)
+
+		logCtx := ctx
+		if p.Addr != nil {
+			logCtx = peer.NewContext(ctx, &p)
+		}
+		var extra []any
+		if c.payloads {
+			extra = append(extra, glog.Object("grpc.request", payload{req}))
+			if err == nil {
+				extra = append(extra, glog.Object("grpc.response", payload{reply}))
+			}
+		}
+		c.log(logCtx, logger, "client", method, start, err, extra...)
+		return err
+	}
+}
+
+// StreamClientInterceptor logs streaming calls made by the client once the
+// stream ends: on an error or io.EOF from RecvMsg, on the response of a
+// client-streaming call, or when the context of the call is done.
+func StreamClientInterceptor(logger *glog.Logger, opts ...Option) grpc.StreamClientInterceptor {
+	c := newConfig(opts)
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, callOpts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx = c.outgoingContext(ctx)
+		if !c.decider(method) {
+			return streamer(ctx, desc, cc, method, callOpts...)
+		}
+
+		start := time.Now()
+		p := &peer.Peer{}
+		cs, err := streamer(ctx, desc, cc, method, append(callOpts, grpc.Peer(p))...)
+		if err != nil {
+			c.log(ctx, logger, "client", method, start, err)
+			return nil, err
+		}
+		return newClientStream(ctx, cs, desc, func(stream *clientStream, err error) {
+			logCtx := ctx
+			// grpc fills p when it finishes the stream, which is not yet
+			// ordered before a canceled stream is logged.
+			if !stream.canceled && p.Addr != nil {
+				logCtx = peer.NewContext(ctx, p)
+			}
+			c.log(logCtx, logger, "client", method, start, err,
+				glog.Int64("grpc.msgs_sent", atomic.LoadInt64(&stream.sent)),
+				glog.Int64("grpc.msgs_received", atomic.LoadInt64(&stream.received)),
+			)
+		}), nil
+	}
+}
@@ -0,0 +1,297 @@
+package grpclog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ace-zhaoy/glog"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) entries(t *testing.T) []map[string]any {
+	t.Helper()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(b.buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		m := map[string]any{}
+		assert.NoError(t, json.Unmarshal([]byte(line), &m), "Expected valid JSON output")
+		entries = append(entries, m)
+	}
+	return entries
+}
+
+func newTestLogger(w *syncBuffer, opts ...glog.Option) *glog.Logger {
+	encoder := zapcore.NewJSONEncoder(zapcore.EncoderConfig{MessageKey: "msg", LevelKey: "level", EncodeLevel: zapcore.LowercaseLevelEncoder})
+	return glog.NewLogger(zapcore.NewCore(encoder, zapcore.AddSync(w), zapcore.DebugLevel), opts...)
+}
+
+func logAll(string) bool {
+	return true
+}
+
+// uploadDesc describes a client-streaming method that counts the requests it
+// receives, reusing the health messages.
+var uploadDesc = grpc.ServiceDesc{
+	ServiceName: "test.Upload",
+	HandlerType: (*any)(nil),
+	Streams: []grpc.StreamDesc{{
+		StreamName:    "Upload",
+		ClientStreams: true,
+		Handler: func(_ any, stream grpc.ServerStream) error {
+			for {
+				err := stream.RecvMsg(&healthpb.HealthCheckRequest{})
+				if errors.Is(err, io.EOF) {
+					return stream.SendMsg(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING})
+				}
+				if err != nil {
+					return err
+				}
+			}
+		},
+	}},
+}
+
+type testEnv struct {
+	conn   *grpc.ClientConn
+	client healthpb.HealthClient
+	health *health.Server
+	server *syncBuffer
+	caller *syncBuffer
+}
+
+func newTestEnv(t *testing.T, serverOpts, clientOpts []Option) *testEnv {
+	t.Helper()
+	env := &testEnv{health: health.NewServer(), server: &syncBuffer{}, caller: &syncBuffer{}}
+	serverLogger := newTestLogger(env.server, glog.AddContextHandlers(glog.BuildTypedContextHandler(MetadataKey("x-request-id"))))
+	clientLogger := newTestLogger(env.caller)
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			UnaryServerInterceptor(serverLogger, serverOpts...),
+			func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+				glog.FromContext(ctx).InfoContext(ctx, "in handler")
+				return handler(ctx, req)
+			},
+		),
+		grpc.StreamInterceptor(StreamServerInterceptor(serverLogger, serverOpts...)),
+	)
+	healthpb.RegisterHealthServer(srv, env.health)
+	srv.RegisterService(&uploadDesc, struct{}{})
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(UnaryClientInterceptor(clientLogger, clientOpts...)),
+		grpc.WithStreamInterceptor(StreamClientInterceptor(clientLogger, clientOpts...)),
+	)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = conn.Close()
+		srv.Stop()
+	})
+	env.conn = conn
+	env.client = healthpb.NewHealthClient(conn)
+	return env
+}
+
+func TestUnaryInterceptors(t *testing.T) {
+	opts := []Option{WithDecider(logAll), WithMetadataKeys("x-request-id"), WithPayloads(true)}
+	env := newTestEnv(t, opts, opts)
+
+	ctx := MetadataKey("x-request-id").WithValue(context.Background(), "abc")
+	_, err := env.client.Check(ctx, &healthpb.HealthCheckRequest{})
+	assert.NoError(t, err, "Expected health check to succeed")
+
+	entries := env.server.entries(t)
+	if !assert.Len(t, entries, 2, "Expected handler and server entries") {
+		return
+	}
+	assert.Equal(t, "in handler", entries[0]["msg"], "Expected handler message")
+	assert.Equal(t, "/grpc.health.v1.Health/Check", entries[0]["grpc.method"], "Expected method on context logger")
+	assert.Equal(t, "abc", entries[0]["x-request-id"], "Expected propagated metadata in handler log")
+
+	server := entries[1]
+	assert.Equal(t, DefaultMessage, server["msg"], "Expected server message")
+	assert.Equal(t, "server", server["grpc.kind"], "Expected server kind")
+	assert.Equal(t, "/grpc.health.v1.Health/Check", server["grpc.method"], "Expected method")
+	assert.Equal(t, "OK", server["grpc.code"], "Expected OK code")
+	assert.Equal(t, "info", server["level"], "Expected info level")
+	assert.Equal(t, "abc", server["x-request-id"], "Expected propagated metadata")
+	assert.Contains(t, server, "grpc.peer", "Expected peer")
+	assert.Contains(t, server, "grpc.duration", "Expected duration")
+	assert.Equal(t, map[string]any{"type": "grpc.health.v1.HealthCheckRequest", "size": float64(0)}, server["grpc.request"], "Expected request summary")
+	assert.Equal(t, map[string]any{"type": "grpc.health.v1.HealthCheckResponse", "size": float64(2)}, server["grpc.response"], "Expected response summary")
+
+	entries = env.caller.entries(t)
+	if assert.Len(t, entries, 1, "Expected client entry") {
+		assert.Equal(t, "client", entries[0]["grpc.kind"], "Expected client kind")
+		assert.Equal(t, "OK", entries[0]["grpc.code"], "Expected OK code")
+		assert.Contains(t, entries[0], "grpc.peer", "Expected peer")
+	}
+}
+
+func TestUnaryInterceptors_error(t *testing.T) {
+	opts := []Option{WithDecider(logAll)}
+	env := newTestEnv(t, opts, opts)
+
+	_, err := env.client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "unknown"})
+	assert.Equal(t, codes.NotFound, status.Code(err), "Expected NotFound")
+
+	entries := env.server.entries(t)
+	if assert.Len(t, entries, 2, "Expected handler and server entries") {
+		assert.Equal(t, "NotFound", entries[1]["grpc.code"], "Expected NotFound code")
+		assert.Equal(t, "warn", entries[1]["level"], "Expected warn level")
+		assert.Equal(t, "unknown service", entries[1]["grpc.error"], "Expected error message")
+	}
+	entries = env.caller.entries(t)
+	if assert.Len(t, entries, 1, "Expected client entry") {
+		assert.Equal(t, "warn", entries[0]["level"], "Expected warn level")
+	}
+}
+
+func TestMetadataKey(t *testing.T) {
+	key := MetadataKey("X-Trace-Id")
+	assert.Equal(t, "x-trace-id", key.Name(), "Expected metadata keys to be lower case")
+	assert.Same(t, key, MetadataKey("x-trace-id"), "Expected the same key for the same name")
+	assert.Error(t, glog.RegisterContextKey(glog.NewContextKey[string]("x-trace-id")), "Expected the key to be registered for Config.ContextFields")
+}
+
+func TestDefaultDecider(t *testing.T) {
+	assert.False(t, DefaultDecider("/grpc.health.v1.Health/Check"), "Expected health check to be skipped")
+	assert.False(t, DefaultDecider("/grpc.health.v1.Health/Watch"), "Expected health watch to be skipped")
+	assert.True(t, DefaultDecider("/app.Users/Get"), "Expected other methods to be logged")
+
+	env := newTestEnv(t, nil, nil)
+	_, err := env.client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	assert.NoError(t, err, "Expected health check to succeed")
+
+	entries := env.server.entries(t)
+	if assert.Len(t, entries, 1, "Expected only the handler entry") {
+		assert.Equal(t, "in handler", entries[0]["msg"], "Expected handler message")
+	}
+	assert.Empty(t, env.caller.entries(t), "Expected no client entry")
+}
+
+func TestStreamInterceptors(t *testing.T) {
+	opts := []Option{WithDecider(logAll), WithMetadataKeys("x-request-id")}
+	env := newTestEnv(t, opts, opts)
+
+	ctx, cancel := context.WithCancel(MetadataKey("x-request-id").WithValue(context.Background(), "abc"))
+	stream, err := env.client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	if !assert.NoError(t, err, "Expected watch to start") {
+		cancel()
+		return
+	}
+	resp, err := stream.Recv()
+	assert.NoError(t, err, "Expected first watch response")
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus(), "Expected serving status")
+	cancel()
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Canceled, status.Code(err), "Expected canceled stream")
+
+	assert.Eventually(t, func() bool {
+		return len(env.server.entries(t)) == 1
+	}, time.Second, 10*time.Millisecond, "Expected server entry")
+
+	server := env.server.entries(t)[0]
+	assert.Equal(t, "server", server["grpc.kind"], "Expected server kind")
+	assert.Equal(t, "/grpc.health.v1.Health/Watch", server["grpc.method"], "Expected method")
+	assert.Equal(t, "abc", server["x-request-id"], "Expected propagated metadata")
+	assert.Equal(t, float64(1), server["grpc.msgs_received"], "Expected one received message")
+	assert.GreaterOrEqual(t, server["grpc.msgs_sent"], float64(1), "Expected sent messages")
+
+	entries := env.caller.entries(t)
+	if assert.Len(t, entries, 1, "Expected client entry") {
+		assert.Equal(t, "client", entries[0]["grpc.kind"], "Expected client kind")
+		assert.Equal(t, "Canceled", entries[0]["grpc.code"], "Expected canceled code")
+		assert.Equal(t, float64(1), entries[0]["grpc.msgs_sent"], "Expected one sent message")
+		assert.Equal(t, float64(1), entries[0]["grpc.msgs_received"], "Expected one received message")
+	}
+}
+
+func TestStreamClientInterceptor_clientStreaming(t *testing.T) {
+	opts := []Option{WithDecider(logAll)}
+	env := newTestEnv(t, opts, opts)
+
+	stream, err := env.conn.NewStream(context.Background(), &uploadDesc.Streams[0], "/test.Upload/Upload")
+	if !assert.NoError(t, err, "Expected upload to start") {
+		return
+	}
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, stream.SendMsg(&healthpb.HealthCheckRequest{}), "Expected send to succeed")
+	}
+	assert.NoError(t, stream.CloseSend(), "Expected close to succeed")
+	resp := &healthpb.HealthCheckResponse{}
+	assert.NoError(t, stream.RecvMsg(resp), "Expected the response")
+
+	entries := env.caller.entries(t)
+	if assert.Len(t, entries, 1, "Expected client entry without reading io.EOF") {
+		assert.Equal(t, "/test.Upload/Upload", entries[0]["grpc.method"], "Expected method")
+		assert.Equal(t, "OK", entries[0]["grpc.code"], "Expected OK code")
+		assert.Equal(t, float64(3), entries[0]["grpc.msgs_sent"], "Expected three sent messages")
+		assert.Equal(t, float64(1), entries[0]["grpc.msgs_received"], "Expected one received message")
+	}
+	assert.Eventually(t, func() bool {
+		entries := env.server.entries(t)
+		return len(entries) == 1 && entries[0]["grpc.msgs_received"] == float64(3)
+	}, time.Second, 10*time.Millisecond, "Expected server entry")
+}
+
+func TestStreamClientInterceptor_canceled(t *testing.T) {
+	opts := []Option{WithDecider(logAll)}
+	env := newTestEnv(t, opts, opts)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := env.client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	if !assert.NoError(t, err, "Expected watch to start") {
+		cancel()
+		return
+	}
+	_, err = stream.Recv()
+	assert.NoError(t, err, "Expected first watch response")
+	cancel()
+
+	assert.Eventually(t, func() bool {
+		return len(env.caller.entries(t)) == 1
+	}, time.Second, 10*time.Millisecond, "Expected client entry without another Recv")
+	assert.Equal(t, "Canceled", env.caller.entries(t)[0]["grpc.code"], "Expected canceled code")
+}
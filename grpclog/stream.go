@@ -0,0 +1,121 @@
+package grpclog
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// serverStream replaces the stream context and counts messages. The counters
+// are atomic since SendMsg and RecvMsg may be called from different goroutines.
+type serverStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	sent     int64
+	received int64
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *serverStream) SendMsg(m any) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		atomic.AddInt64(&s.sent, 1)
+	}
+	return err
+}
+
+func (s *serverStream) RecvMsg(m any) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		atomic.AddInt64(&s.received, 1)
+	}
+	return err
+}
+
+// clientStream counts messages and calls finish once the stream ends: when
+// RecvMsg fails or reaches io.EOF, when the single response of a
+// client-streaming call is received, or when the context is done.
+type clientStream struct {
+	grpc.ClientStream
+	serverStreams bool
+	sent          int64
+	received      int64
+
+	once     sync.Once
+	finished chan struct{}
+	// canceled is set when the stream ends because its context is done, in
+	// which case grpc may still be finishing the stream concurrently.
+	canceled bool
+	finish   func(stream *clientStream, err error)
+}
+
+func newClientStream(ctx context.Context, cs grpc.ClientStream, desc *grpc.StreamDesc, finish func(stream *clientStream, err error)) *clientStream {
+	s := &clientStream{
+		ClientStream:  cs,
+		serverStreams: desc.ServerStreams,
+		finished:      make(chan struct{}),
+		finish:        finish,
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.once.Do(func() {
+				s.canceled = true
+				close(s.finished)
+				s.finish(s, status.FromContextError(ctx.Err()).Err())
+			})
+		case <-s.finished:
+		}
+	}()
+	return s
+}
+
+func (s *clientStream) done(err error) {
+	s.once.Do(func() {
+		close(s.finished)
+		s.finish(s, err)
+	})
+}
+
+func (s *clientStream) Header() (md metadata.MD, err error) {
+	md, err = s.ClientStream.Header()
+	if err != nil {
+		s.done(err)
+	}
+	return
+}
+
+func (s *clientStream) SendMsg(m any) error {
+	err := s.ClientStream.SendMsg(m)
+	if err == nil {
+		atomic.AddInt64(&s.sent, 1)
+	} else if !errors.Is(err, io.EOF) {
+		s.done(err)
+	}
+	return err
+}
+
+func (s *clientStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	switch {
+	case err == nil:
+		atomic.AddInt64(&s.received, 1)
+		if !s.serverStreams {
+			s.done(nil)
+		}
+	case errors.Is(err, io.EOF):
+		s.done(nil)
+	default:
+		s.done(err)
+	}
+	return err
+}
@@ -0,0 +1,251 @@
+package httplog
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ace-zhaoy/glog"
+	"github.com/ace-zhaoy/glog/stacktrace"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	DefaultRequestIDHeader = "X-Request-ID"
+	DefaultRequestIDField  = "request_id"
+	DefaultMessage         = "http request"
+)
+
+// RequestIDKey holds the request ID in the request context. Register it with
+// glog.RegisterContextKey, or use glog.BuildTypedContextHandler(RequestIDKey),
+// to record it from loggers that are not taken from the context.
+var RequestIDKey = glog.NewContextKey[string](DefaultRequestIDField)
+
+// RequestID returns the request ID the middleware stored in ctx.
+func RequestID(ctx context.Context) string {
+	id, _ := RequestIDKey.Value(ctx)
+	return id
+}
+
+type config struct {
+	requestIDHeader string
+	requestIDField  string
+	generateID      func() string
+	requestHeaders  []string
+	responseHeaders []string
+	sampleSuccess   uint64
+	levelFunc       func(status int) glog.Level
+	recovery        bool
+	message         string
+	skip            func(r *http.Request) bool
+}
+
+type Option func(*config)
+
+// WithRequestIDHeader sets the header the request ID is read from and written to.
+func WithRequestIDHeader(header string) Option {
+	return func(c *config) {
+		c.requestIDHeader = header
+	}
+}
+
+// WithRequestIDField sets the field key of the request ID.
+func WithRequestIDField(key string) Option {
+	return func(c *config) {
+		c.requestIDField = key
+	}
+}
+
+// WithRequestIDGenerator sets the function that generates IDs for requests
+// without one.
+func WithRequestIDGenerator(f func() string) Option {
+	return func(c *config) {
+		c.generateID = f
+	}
+}
+
+// WithRequestHeaders records the named request headers in the access log.
+func WithRequestHeaders(names ...string) Option {
+	return func(c *config) {
+		c.requestHeaders = append(c.requestHeaders, names...)
+	}
+}
+
+// WithResponseHeaders records the named response headers in the access log.
+func WithResponseHeaders(names ...string) Option {
+	return func(c *config) {
+		c.responseHeaders = append(c.responseHeaders, names...)
+	}
+}
+
+// WithSuccessSampling logs only the first of every n 2xx responses.
+// Other responses are always logged.
+func WithSuccessSampling(n int) Option {
+	return func(c *config) {
+		if n < 1 {
+			n = 1
+		}
+		c.sampleSuccess = uint64(n)
+	}
+}
+
+// WithLevelFunc sets the function that picks the access log level from the
+// response status.
+func WithLevelFunc(f func(status int) glog.Level) Option {
+	return func(c *config) {
+		c.levelFunc = f
+	}
+}
+
+// WithRecovery enables recovering from panics in the handler, which is the default.
+func WithRecovery(enabled bool) Option {
+	return func(c *config) {
+		c.recovery = enabled
+	}
+}
+
+// WithMessage sets the message of the access log.
+func WithMessage(msg string) Option {
+	return func(c *config) {
+		c.message = msg
+	}
+}
+
+// WithSkip skips the access log for requests f returns true for.
+func WithSkip(f func(r *http.Request) bool) Option {
+	return func(c *config) {
+		c.skip = f
+	}
+}
+
+// DefaultLevel logs 5xx responses at error level, 4xx at warn and others at info.
+func DefaultLevel(status int) glog.Level {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return glog.LevelError
+	case status >= http.StatusBadRequest:
+		return glog.LevelWarn
+	default:
+		return glog.LevelInfo
+	}
+}
+
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// Middleware returns a middleware that propagates or generates a request ID,
+// stores a logger carrying it in the request context for glog.FromContext,
+// and writes an access log when the handler returns.
+func Middleware(logger *glog.Logger, opts ...Option) func(http.Handler) http.Handler {
+	c := &config{
+		requestIDHeader: DefaultRequestIDHeader,
+		requestIDField:  DefaultRequestIDField,
+		generateID:      newRequestID,
+		sampleSuccess:   1,
+		levelFunc:       DefaultLevel,
+		recovery:        true,
+		message:         DefaultMessage,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	var successCount uint64
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			id := ""
+			if c.requestIDHeader != "" {
+				id = r.Header.Get(c.requestIDHeader)
+			}
+			if id == "" {
+				id = c.generateID()
+			}
+			if c.requestIDHeader != "" {
+				w.Header().Set(c.requestIDHeader, id)
+			}
+
+			reqLogger := logger
+			if c.requestIDField != "" {
+				reqLogger = logger.With(glog.String(c.requestIDField, id))
+			}
+			ctx := RequestIDKey.WithValue(r.Context(), id)
+			ctx = glog.NewContext(ctx, reqLogger)
+			r = r.WithContext(ctx)
+
+			rw := &responseWriter{ResponseWriter: w}
+			defer func() {
+				var recovered any
+				if c.recovery {
+					if recovered = recover(); recovered != nil {
+						if recovered == http.ErrAbortHandler {
+							panic(recovered)
+						}
+						reqLogger.ErrorContext(ctx, "panic recovered",
+							glog.Any("panic", recovered),
+							glog.String("stacktrace", stacktrace.Take(2)),
+						)
+						if !rw.wroteHeader {
+							rw.WriteHeader(http.StatusInternalServerError)
+						}
+					}
+				}
+
+				if c.skip != nil && c.skip(r) {
+					return
+				}
+				status := rw.Status()
+				if recovered == nil && status >= 200 && status < 300 && c.sampleSuccess > 1 {
+					if (atomic.AddUint64(&successCount, 1)-1)%c.sampleSuccess != 0 {
+						return
+					}
+				}
+
+				fields := []any{
+					glog.String("method", r.Method),
+					glog.String("path", r.URL.Path),
+					glog.Int("status", status),
+					glog.Int64("bytes", rw.bytes),
+					glog.Duration("latency", time.Since(start)),
+					glog.String("remote_addr", r.RemoteAddr),
+				}
+				if len(c.requestHeaders) > 0 {
+					fields = append(fields, glog.Object("request_headers", headers{r.Header, c.requestHeaders}))
+				}
+				if len(c.responseHeaders) > 0 {
+					fields = append(fields, glog.Object("response_headers", headers{rw.Header(), c.responseHeaders}))
+				}
+				reqLogger.LogContext(ctx, c.levelFunc(status), c.message, fields...)
+			}()
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// headers marshals the named headers that are present.
+type headers struct {
+	header http.Header
+	names  []string
+}
+
+func (h headers) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, name := range h.names {
+		values := h.header.Values(name)
+		if len(values) > 0 {
+			enc.AddString(name, strings.Join(values, ", "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package httplog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ace-zhaoy/glog"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func newTestLogger(buf *bytes.Buffer) *glog.Logger {
+	encoder := zapcore.NewJSONEncoder(zapcore.EncoderConfig{MessageKey: "msg", LevelKey: "level", EncodeLevel: zapcore.LowercaseLevelEncoder})
+	return glog.NewLogger(zapcore.NewCore(encoder, zapcore.AddSync(buf), zapcore.DebugLevel))
+}
+
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		m := map[string]any{}
+		assert.NoError(t, json.Unmarshal([]byte(line), &m), "Expected valid JSON output")
+		entries = append(entries, m)
+	}
+	buf.Reset()
+	return entries
+}
+
+func serve(h http.Handler, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestMiddleware(t *testing.T) {
+	buf := &bytes.Buffer{}
+	var fromContext, requestID string
+	h := Middleware(newTestLogger(buf), WithRequestHeaders("User-Agent"), WithResponseHeaders("Content-Type"))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID = RequestID(r.Context())
+			glog.FromContext(r.Context()).Info("in handler")
+			fromContext = "ok"
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = w.Write([]byte("hello"))
+		}),
+	)
+
+	r := httptest.NewRequest(http.MethodGet, "/users?id=1", nil)
+	r.Header.Set("User-Agent", "test-agent")
+	w := serve(h, r)
+
+	assert.Equal(t, "ok", fromContext, "Expected handler to run")
+	assert.Len(t, requestID, 32, "Expected generated request id")
+	assert.Equal(t, requestID, w.Header().Get(DefaultRequestIDHeader), "Expected request id response header")
+
+	entries := decodeLines(t, buf)
+	if !assert.Len(t, entries, 2, "Expected handler and access log entries") {
+		return
+	}
+	assert.Equal(t, "in handler", entries[0]["msg"], "Expected handler message")
+	assert.Equal(t, requestID, entries[0][DefaultRequestIDField], "Expected request id in handler log")
+
+	access := entries[1]
+	assert.Equal(t, DefaultMessage, access["msg"], "Expected access log message")
+	assert.Equal(t, "info", access["level"], "Expected info level for 2xx")
+	assert.Equal(t, requestID, access[DefaultRequestIDField], "Expected request id in access log")
+	assert.Equal(t, "GET", access["method"], "Expected method")
+	assert.Equal(t, "/users", access["path"], "Expected path")
+	assert.Equal(t, float64(200), access["status"], "Expected status")
+	assert.Equal(t, float64(5), access["bytes"], "Expected bytes")
+	assert.Contains(t, access, "latency", "Expected latency")
+	assert.Equal(t, map[string]any{"User-Agent": "test-agent"}, access["request_headers"], "Expected request headers")
+	assert.Equal(t, map[string]any{"Content-Type": "text/plain"}, access["response_headers"], "Expected response headers")
+}
+
+func TestMiddleware_propagateRequestID(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := Middleware(newTestLogger(buf), WithRequestIDHeader("X-Trace"), WithRequestIDField("rid"))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+	)
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Trace", "abc")
+	w := serve(h, r)
+
+	assert.Equal(t, "abc", w.Header().Get("X-Trace"), "Expected propagated request id")
+	entries := decodeLines(t, buf)
+	if assert.Len(t, entries, 1, "Expected access log entry") {
+		assert.Equal(t, "abc", entries[0]["rid"], "Expected request id under custom key")
+	}
+}
+
+func TestMiddleware_level(t *testing.T) {
+	buf := &bytes.Buffer{}
+	status := 0
+	h := Middleware(newTestLogger(buf))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}),
+	)
+
+	for _, tt := range []struct {
+		status int
+		level  string
+	}{
+		{http.StatusOK, "info"},
+		{http.StatusFound, "info"},
+		{http.StatusNotFound, "warn"},
+		{http.StatusServiceUnavailable, "error"},
+	} {
+		status = tt.status
+		serve(h, httptest.NewRequest(http.MethodGet, "/", nil))
+		entries := decodeLines(t, buf)
+		if assert.Len(t, entries, 1, "Expected access log entry") {
+			assert.Equal(t, tt.level, entries[0]["level"], "Expected level for status %d", tt.status)
+		}
+	}
+}
+
+func TestMiddleware_sampling(t *testing.T) {
+	buf := &bytes.Buffer{}
+	status := http.StatusOK
+	h := Middleware(newTestLogger(buf), WithSuccessSampling(3))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}),
+	)
+
+	for i := 0; i < 6; i++ {
+		serve(h, httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+	assert.Len(t, decodeLines(t, buf), 2, "Expected one of every three 2xx responses")
+
+	status = http.StatusBadRequest
+	for i := 0; i < 3; i++ {
+		serve(h, httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+	assert.Len(t, decodeLines(t, buf), 3, "Expected all 4xx responses")
+}
+
+func TestMiddleware_skip(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := Middleware(newTestLogger(buf), WithSkip(func(r *http.Request) bool {
+		return r.URL.Path == "/healthz"
+	}))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve(h, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Empty(t, buf.String(), "Expected no access log for skipped request")
+}
+
+func panickingHandler(w http.ResponseWriter, r *http.Request) {
+	panic("boom")
+}
+
+func TestMiddleware_recovery(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := Middleware(newTestLogger(buf))(http.HandlerFunc(panickingHandler))
+
+	w := serve(h, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code, "Expected 500 after panic")
+
+	entries := decodeLines(t, buf)
+	if !assert.Len(t, entries, 2, "Expected panic and access log entries") {
+		return
+	}
+	assert.Equal(t, "panic recovered", entries[0]["msg"], "Expected panic message")
+	assert.Equal(t, "boom", entries[0]["panic"], "Expected panic value")
+	assert.Contains(t, entries[0]["stacktrace"], "panickingHandler", "Expected stacktrace of the panicking handler")
+	assert.Equal(t, float64(500), entries[1]["status"], "Expected status 500 in access log")
+	assert.Equal(t, "error", entries[1]["level"], "Expected error level in access log")
+
+	h = Middleware(newTestLogger(buf), WithRecovery(false))(http.HandlerFunc(panickingHandler))
+	assert.Panics(t, func() {
+		serve(h, httptest.NewRequest(http.MethodGet, "/", nil))
+	}, "Expected panic without recovery")
+}
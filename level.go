@@ -8,12 +8,23 @@ import (
 type Level = zapcore.Level
 
 const (
-	LevelDebug = zapcore.DebugLevel
-	LevelInfo  = zapcore.InfoLevel
-	LevelWarn  = zapcore.WarnLevel
-	LevelError = zapcore.ErrorLevel
+	LevelDebug  = zapcore.DebugLevel
+	LevelInfo   = zapcore.InfoLevel
+	LevelWarn   = zapcore.WarnLevel
+	LevelError  = zapcore.ErrorLevel
+	LevelDPanic = zapcore.DPanicLevel
+	LevelPanic  = zapcore.PanicLevel
+	LevelFatal  = zapcore.FatalLevel
 )
 
 type LevelEnabler = zapcore.LevelEnabler
 
 type LevelEnablerFunc = zap.LevelEnablerFunc
+
+// AtomicLevel is a level that can be changed at runtime. It implements
+// http.Handler, serving GET and PUT of the level in JSON or form encoding.
+type AtomicLevel = zap.AtomicLevel
+
+func NewAtomicLevelAt(lvl Level) AtomicLevel {
+	return zap.NewAtomicLevelAt(lvl)
+}
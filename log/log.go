@@ -3,18 +3,43 @@ package log
 import (
 	"context"
 	"github.com/ace-zhaoy/glog"
+	"net/http"
 	"sync/atomic"
 	"unsafe"
 )
 
-var logger unsafe.Pointer
+var (
+	logger unsafe.Pointer
+	// contextLogger is logger without the caller skip of this package,
+	// handed out by glog.FromContext for direct use.
+	contextLogger unsafe.Pointer
+)
 
 func Logger() *glog.Logger {
 	return (*glog.Logger)(atomic.LoadPointer(&logger))
 }
 
 func SetLogger(l *glog.Logger) {
+	var cl *glog.Logger
+	if l != nil {
+		cl = l.WithOptions(glog.AddCallerSkip(-1))
+	}
+	atomic.StorePointer(&contextLogger, unsafe.Pointer(cl))
 	atomic.StorePointer(&logger, unsafe.Pointer(l))
+	updateNamed(l)
+}
+
+func fallbackLogger() *glog.Logger {
+	return (*glog.Logger)(atomic.LoadPointer(&contextLogger))
+}
+
+// fromContext returns the logger stored in ctx, adjusted for the caller skip
+// of this package, or the global logger.
+func fromContext(ctx context.Context) *glog.Logger {
+	if l, ok := glog.WrapperLoggerFromContext(ctx); ok {
+		return l
+	}
+	return Logger()
 }
 
 func init() {
@@ -26,6 +51,22 @@ func init() {
 		panic(err)
 	}
 	SetLogger(l)
+	glog.SetFallbackLogger(fallbackLogger)
+}
+
+// Level returns the level of the global logger.
+func Level() glog.Level {
+	return Logger().Level()
+}
+
+// SetLevel changes the level of the global logger and of every logger derived from it.
+func SetLevel(lvl glog.Level) {
+	Logger().SetLevel(lvl)
+}
+
+// LevelHandler returns an http.Handler that reads and changes the level of the global logger.
+func LevelHandler() http.Handler {
+	return Logger().LevelHandler()
 }
 
 func WithFormatEnable() *glog.Logger {
@@ -37,7 +78,7 @@ func WithFormatDisable() *glog.Logger {
 }
 
 func LogContext(ctx context.Context, lvl glog.Level, msg string, args ...any) {
-	Logger().LogContext(ctx, lvl, msg, args...)
+	fromContext(ctx).LogContext(ctx, lvl, msg, args...)
 }
 
 func Log(lvl glog.Level, msg string, args ...any) {
@@ -49,7 +90,7 @@ func Debug(msg string, args ...any) {
 }
 
 func DebugContext(ctx context.Context, msg string, args ...any) {
-	Logger().DebugContext(ctx, msg, args...)
+	fromContext(ctx).DebugContext(ctx, msg, args...)
 }
 
 func Info(msg string, args ...any) {
@@ -57,7 +98,7 @@ func Info(msg string, args ...any) {
 }
 
 func InfoContext(ctx context.Context, msg string, args ...any) {
-	Logger().InfoContext(ctx, msg, args...)
+	fromContext(ctx).InfoContext(ctx, msg, args...)
 }
 
 func Warn(msg string, args ...any) {
@@ -65,7 +106,7 @@ func Warn(msg string, args ...any) {
 }
 
 func WarnContext(ctx context.Context, msg string, args ...any) {
-	Logger().WarnContext(ctx, msg, args...)
+	fromContext(ctx).WarnContext(ctx, msg, args...)
 }
 
 func Error(msg string, args ...any) {
@@ -73,7 +114,95 @@ func Error(msg string, args ...any) {
 }
 
 func ErrorContext(ctx context.Context, msg string, args ...any) {
-	Logger().ErrorContext(ctx, msg, args...)
+	fromContext(ctx).ErrorContext(ctx, msg, args...)
+}
+
+func DPanic(msg string, args ...any) {
+	Logger().DPanic(msg, args...)
+}
+
+func DPanicContext(ctx context.Context, msg string, args ...any) {
+	fromContext(ctx).DPanicContext(ctx, msg, args...)
+}
+
+func Panic(msg string, args ...any) {
+	Logger().Panic(msg, args...)
+}
+
+func PanicContext(ctx context.Context, msg string, args ...any) {
+	fromContext(ctx).PanicContext(ctx, msg, args...)
+}
+
+func Fatal(msg string, args ...any) {
+	Logger().Fatal(msg, args...)
+}
+
+func FatalContext(ctx context.Context, msg string, args ...any) {
+	fromContext(ctx).FatalContext(ctx, msg, args...)
+}
+
+func LogfContext(ctx context.Context, lvl glog.Level, format string, args ...any) {
+	fromContext(ctx).LogfContext(ctx, lvl, format, args...)
+}
+
+func Logf(lvl glog.Level, format string, args ...any) {
+	Logger().Logf(lvl, format, args...)
+}
+
+func Debugf(format string, args ...any) {
+	Logger().Debugf(format, args...)
+}
+
+func DebugfContext(ctx context.Context, format string, args ...any) {
+	fromContext(ctx).DebugfContext(ctx, format, args...)
+}
+
+func Infof(format string, args ...any) {
+	Logger().Infof(format, args...)
+}
+
+func InfofContext(ctx context.Context, format string, args ...any) {
+	fromContext(ctx).InfofContext(ctx, format, args...)
+}
+
+func Warnf(format string, args ...any) {
+	Logger().Warnf(format, args...)
+}
+
+func WarnfContext(ctx context.Context, format string, args ...any) {
+	fromContext(ctx).WarnfContext(ctx, format, args...)
+}
+
+func Errorf(format string, args ...any) {
+	Logger().Errorf(format, args...)
+}
+
+func ErrorfContext(ctx context.Context, format string, args ...any) {
+	fromContext(ctx).ErrorfContext(ctx, format, args...)
+}
+
+func DPanicf(format string, args ...any) {
+	Logger().DPanicf(format, args...)
+}
+
+func DPanicfContext(ctx context.Context, format string, args ...any) {
+	fromContext(ctx).DPanicfContext(ctx, format, args...)
+}
+
+func Panicf(format string, args ...any) {
+	Logger().Panicf(format, args...)
+}
+
+func PanicfContext(ctx context.Context, format string, args ...any) {
+	fromContext(ctx).PanicfContext(ctx, format, args...)
+}
+
+func Fatalf(format string, args ...any) {
+	Logger().Fatalf(format, args...)
+}
+
+func FatalfContext(ctx context.Context, format string, args ...any) {
+	fromContext(ctx).FatalfContext(ctx, format, args...)
 }
 
 func Sync() error {
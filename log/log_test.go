@@ -1,10 +1,13 @@
 package log
 
 import (
+	"bytes"
+	"context"
 	"testing"
 
 	"github.com/ace-zhaoy/glog"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
 )
 
 func TestLogger(t *testing.T) {
@@ -19,6 +22,16 @@ func TestSetLogger(t *testing.T) {
 	assert.Equal(t, l, Logger(), "Expected logger to be set and retrieved correctly")
 }
 
+func TestSetLogger_nil(t *testing.T) {
+	oldLogger := Logger()
+	defer SetLogger(oldLogger)
+
+	Named("nil")
+	assert.NotPanics(t, func() { SetLogger(nil) })
+	assert.Nil(t, Logger())
+	assert.Nil(t, Named("nil").Logger())
+}
+
 func TestWithFormatEnable(t *testing.T) {
 	l := &glog.Logger{}
 	SetLogger(l)
@@ -30,3 +43,96 @@ func TestWithFormatDisable(t *testing.T) {
 	SetLogger(l)
 	assert.NotNil(t, WithFormatDisable(), "Expected WithFormatDisable to return a logger")
 }
+
+func TestPanic(t *testing.T) {
+	oldLogger := Logger()
+	defer SetLogger(oldLogger)
+
+	SetLogger(oldLogger.WithOptions(glog.WrapCore(func(core glog.Core) glog.Core {
+		return zapcore.NewNopCore()
+	})))
+	assert.PanicsWithValue(t, "test panic", func() {
+		Panic("test panic")
+	}, "Expected Panic to panic with the message")
+}
+
+func TestFatal(t *testing.T) {
+	oldLogger := Logger()
+	defer SetLogger(oldLogger)
+
+	exitCode := -1
+	SetLogger(oldLogger.WithOptions(
+		glog.WrapCore(func(core glog.Core) glog.Core {
+			return zapcore.NewNopCore()
+		}),
+		glog.WithExitFunc(func(code int) {
+			exitCode = code
+		}),
+	))
+	Fatal("test fatal")
+	assert.Equal(t, 1, exitCode, "Expected Fatal to call the exit func")
+}
+
+func TestSetLevel(t *testing.T) {
+	oldLogger := Logger()
+	defer SetLogger(oldLogger)
+
+	l, err := glog.NewDefaultConfig().Build()
+	assert.NoError(t, err)
+	SetLogger(l)
+	derived := Logger().With("key", "value")
+
+	SetLevel(glog.LevelError)
+	assert.Equal(t, glog.LevelError, Level(), "Expected global level to be changed")
+	assert.False(t, derived.Enabled(glog.LevelWarn), "Expected derived logger to follow the global level")
+	assert.NotNil(t, LevelHandler(), "Expected level handler to be returned")
+}
+
+func TestFromContext(t *testing.T) {
+	oldLogger := Logger()
+	defer SetLogger(oldLogger)
+
+	buf := &bytes.Buffer{}
+	core := zapcore.NewCore(
+		zapcore.NewJSONEncoder(zapcore.EncoderConfig{MessageKey: "msg", CallerKey: "caller", EncodeCaller: zapcore.ShortCallerEncoder}),
+		zapcore.AddSync(buf),
+		glog.LevelDebug,
+	)
+	SetLogger(glog.NewLogger(core, glog.AddCaller(), glog.AddCallerSkip(1)))
+
+	glog.FromContext(context.Background()).Info("fallback")
+	assert.Contains(t, buf.String(), `"caller":"log/log_test.go:`, "Expected fallback logger to report the right caller")
+	assert.NotContains(t, buf.String(), "log/log.go", "Expected fallback logger to report the right caller")
+
+	buf.Reset()
+	l := glog.FromContext(context.Background()).With("user", "ace")
+	ctx := glog.NewContext(context.Background(), l)
+	InfoContext(ctx, "stored")
+	assert.Contains(t, buf.String(), `"user":"ace"`, "Expected log package to use the logger from the context")
+	assert.Contains(t, buf.String(), `"caller":"log/log_test.go:`, "Expected stored logger to report the right caller")
+}
+
+func TestInfof(t *testing.T) {
+	oldLogger := Logger()
+	defer SetLogger(oldLogger)
+
+	buf := &bytes.Buffer{}
+	core := zapcore.NewCore(
+		zapcore.NewJSONEncoder(zapcore.EncoderConfig{MessageKey: "msg", CallerKey: "caller", EncodeCaller: zapcore.ShortCallerEncoder}),
+		zapcore.AddSync(buf),
+		glog.LevelDebug,
+	)
+	SetLogger(glog.NewLogger(core, glog.AddCaller(), glog.AddCallerSkip(1), glog.AddMsgTemplate()))
+
+	Infof("user %s", "bob")
+	assert.Contains(t, buf.String(), `"msg":"user bob"`)
+	assert.Contains(t, buf.String(), `"msg_template":"user %s"`)
+	assert.Contains(t, buf.String(), `"caller":"log/log_test.go:`, "Expected the caller of Infof")
+
+	buf.Reset()
+	ctx := glog.NewContext(context.Background(), glog.FromContext(context.Background()).With("user", "ace"))
+	ErrorfContext(ctx, "failed %d times", 3)
+	assert.Contains(t, buf.String(), `"msg":"failed 3 times"`)
+	assert.Contains(t, buf.String(), `"user":"ace"`, "Expected the logger from the context")
+	assert.Contains(t, buf.String(), `"caller":"log/log_test.go:`, "Expected the caller of ErrorfContext")
+}
@@ -0,0 +1,204 @@
+package log
+
+import (
+	"context"
+	"github.com/ace-zhaoy/glog"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+var (
+	namedMu sync.Mutex
+	named   = make(map[string]*NamedLogger)
+)
+
+// NamedLogger is a child of the global logger named with glog.Logger.Named.
+// It follows SetLogger, so it can be kept in a package-level variable:
+//
+//	var logger = log.Named("db")
+type NamedLogger struct {
+	name string
+	// logger has the caller skip of this package, contextLogger does not.
+	logger        unsafe.Pointer
+	contextLogger unsafe.Pointer
+}
+
+// Named returns the NamedLogger for name, the same one for every call.
+func Named(name string) *NamedLogger {
+	namedMu.Lock()
+	defer namedMu.Unlock()
+
+	if n, ok := named[name]; ok {
+		return n
+	}
+	n := &NamedLogger{name: name}
+	n.update(Logger())
+	named[name] = n
+	return n
+}
+
+// updateNamed points the named loggers to l, called by SetLogger.
+func updateNamed(l *glog.Logger) {
+	namedMu.Lock()
+	defer namedMu.Unlock()
+
+	for _, n := range named {
+		n.update(l)
+	}
+}
+
+func (n *NamedLogger) update(l *glog.Logger) {
+	var cl *glog.Logger
+	if l != nil {
+		l = l.Named(n.name)
+		cl = l.WithOptions(glog.AddCallerSkip(-1))
+	}
+	atomic.StorePointer(&n.contextLogger, unsafe.Pointer(cl))
+	atomic.StorePointer(&n.logger, unsafe.Pointer(l))
+}
+
+func (n *NamedLogger) load() *glog.Logger {
+	return (*glog.Logger)(atomic.LoadPointer(&n.logger))
+}
+
+func (n *NamedLogger) Name() string {
+	return n.name
+}
+
+// Logger returns the current child of the global logger, for direct use.
+func (n *NamedLogger) Logger() *glog.Logger {
+	return (*glog.Logger)(atomic.LoadPointer(&n.contextLogger))
+}
+
+func (n *NamedLogger) Enabled(lvl glog.Level) bool {
+	return n.load().Enabled(lvl)
+}
+
+func (n *NamedLogger) LogContext(ctx context.Context, lvl glog.Level, msg string, args ...any) {
+	n.load().LogContext(ctx, lvl, msg, args...)
+}
+
+func (n *NamedLogger) Log(lvl glog.Level, msg string, args ...any) {
+	n.load().Log(lvl, msg, args...)
+}
+
+func (n *NamedLogger) Debug(msg string, args ...any) {
+	n.load().Debug(msg, args...)
+}
+
+func (n *NamedLogger) DebugContext(ctx context.Context, msg string, args ...any) {
+	n.load().DebugContext(ctx, msg, args...)
+}
+
+func (n *NamedLogger) Info(msg string, args ...any) {
+	n.load().Info(msg, args...)
+}
+
+func (n *NamedLogger) InfoContext(ctx context.Context, msg string, args ...any) {
+	n.load().InfoContext(ctx, msg, args...)
+}
+
+func (n *NamedLogger) Warn(msg string, args ...any) {
+	n.load().Warn(msg, args...)
+}
+
+func (n *NamedLogger) WarnContext(ctx context.Context, msg string, args ...any) {
+	n.load().WarnContext(ctx, msg, args...)
+}
+
+func (n *NamedLogger) Error(msg string, args ...any) {
+	n.load().Error(msg, args...)
+}
+
+func (n *NamedLogger) ErrorContext(ctx context.Context, msg string, args ...any) {
+	n.load().ErrorContext(ctx, msg, args...)
+}
+
+func (n *NamedLogger) DPanic(msg string, args ...any) {
+	n.load().DPanic(msg, args...)
+}
+
+func (n *NamedLogger) DPanicContext(ctx context.Context, msg string, args ...any) {
+	n.load().DPanicContext(ctx, msg, args...)
+}
+
+func (n *NamedLogger) Panic(msg string, args ...any) {
+	n.load().Panic(msg, args...)
+}
+
+func (n *NamedLogger) PanicContext(ctx context.Context, msg string, args ...any) {
+	n.load().PanicContext(ctx, msg, args...)
+}
+
+func (n *NamedLogger) Fatal(msg string, args ...any) {
+	n.load().Fatal(msg, args...)
+}
+
+func (n *NamedLogger) FatalContext(ctx context.Context, msg string, args ...any) {
+	n.load().FatalContext(ctx, msg, args...)
+}
+
+func (n *NamedLogger) LogfContext(ctx context.Context, lvl glog.Level, format string, args ...any) {
+	n.load().LogfContext(ctx, lvl, format, args...)
+}
+
+func (n *NamedLogger) Logf(lvl glog.Level, format string, args ...any) {
+	n.load().Logf(lvl, format, args...)
+}
+
+func (n *NamedLogger) Debugf(format string, args ...any) {
+	n.load().Debugf(format, args...)
+}
+
+func (n *NamedLogger) DebugfContext(ctx context.Context, format string, args ...any) {
+	n.load().DebugfContext(ctx, format, args...)
+}
+
+func (n *NamedLogger) Infof(format string, args ...any) {
+	n.load().Infof(format, args...)
+}
+
+func (n *NamedLogger) InfofContext(ctx context.Context, format string, args ...any) {
+	n.load().InfofContext(ctx, format, args...)
+}
+
+func (n *NamedLogger) Warnf(format string, args ...any) {
+	n.load().Warnf(format, args...)
+}
+
+func (n *NamedLogger) WarnfContext(ctx context.Context, format string, args ...any) {
+	n.load().WarnfContext(ctx, format, args...)
+}
+
+func (n *NamedLogger) Errorf(format string, args ...any) {
+	n.load().Errorf(format, args...)
+}
+
+func (n *NamedLogger) ErrorfContext(ctx context.Context, format string, args ...any) {
+	n.load().ErrorfContext(ctx, format, args...)
+}
+
+func (n *NamedLogger) DPanicf(format string, args ...any) {
+	n.load().DPanicf(format, args...)
+}
+
+func (n *NamedLogger) DPanicfContext(ctx context.Context, format string, args ...any) {
+	n.load().DPanicfContext(ctx, format, args...)
+}
+
+func (n *NamedLogger) Panicf(format string, args ...any) {
+	n.load().Panicf(format, args...)
+}
+
+func (n *NamedLogger) PanicfContext(ctx context.Context, format string, args ...any) {
+	n.load().PanicfContext(ctx, format, args...)
+}
+
+func (n *NamedLogger) Fatalf(format string, args ...any) {
+	n.load().Fatalf(format, args...)
+}
+
+func (n *NamedLogger) FatalfContext(ctx context.Context, format string, args ...any) {
+	n.load().FatalfContext(ctx, format, args...)
+}
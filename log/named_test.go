@@ -0,0 +1,43 @@
+package log
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ace-zhaoy/glog"
+	"github.com/ace-zhaoy/glog/glogtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamed(t *testing.T) {
+	oldLogger := Logger()
+	defer SetLogger(oldLogger)
+
+	n := Named("named_test")
+	assert.Same(t, n, Named("named_test"), "Expected the same logger for a name")
+	assert.Equal(t, "named_test", n.Name())
+
+	l, logs := glogtest.NewObserved(glog.WithName("app"), glog.AddCaller(), glog.AddCallerSkip(1))
+	SetLogger(l)
+	n.Info("after SetLogger", "key", "value")
+	n.Logger().Warn("direct")
+	Named("named_test.child").Debug("child")
+	n.Errorf("failed %d times", 2)
+
+	entries := logs.All()
+	if assert.Len(t, entries, 4, "Expected the named logger to follow SetLogger") {
+		assert.Equal(t, "app.named_test", entries[0].LoggerName)
+		assert.Equal(t, "app.named_test", entries[1].LoggerName)
+		assert.Equal(t, "app.named_test.child", entries[2].LoggerName)
+		assert.Equal(t, "failed 2 times", entries[3].Message)
+		for _, e := range entries {
+			assert.Equal(t, "named_test.go", filepath.Base(e.Caller.File), "Unexpected caller for %q", e.Message)
+		}
+	}
+
+	l2, logs2 := glogtest.NewObserved()
+	SetLogger(l2)
+	n.Info("after second SetLogger")
+	assert.Equal(t, 4, logs.Len(), "Expected the named logger to follow SetLogger again")
+	assert.Equal(t, []string{"after second SetLogger"}, logs2.Messages())
+}
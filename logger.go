@@ -3,13 +3,20 @@ package glog
 import (
 	"context"
 	"fmt"
+	"github.com/ace-zhaoy/glog/cores"
 	"github.com/ace-zhaoy/glog/stacktrace"
 	"go.uber.org/zap/zapcore"
+	"net/http"
+	"os"
 	"time"
 )
 
 const (
 	callerSkipOffset = 3
+
+	DefaultNameSeparator = "."
+
+	DefaultMsgTemplateKey = "msg_template"
 )
 
 func NewDefault(opts ...Option) (*Logger, error) {
@@ -18,18 +25,47 @@ func NewDefault(opts ...Option) (*Logger, error) {
 
 type Logger struct {
 	core       Core
+	level      *AtomicLevel
+	nameLevels *NameLevels
 	name       string
 	addCaller  bool
 	stackLevel LevelEnabler
 	callerSkip int
 
+	// nameSeparator joins the names given to Named, DefaultNameSeparator if empty.
+	nameSeparator string
+
 	formatEnabled   bool
 	contextHandlers []ContextHandler
+
+	// msgTemplateKey is the key of the format string of Debugf and the like,
+	// which is not recorded if empty.
+	msgTemplateKey  string
+	msgPlaceholders bool
+	// withFields are the fields added with With and WithContext while
+	// msgPlaceholders is set, which placeholders are also filled from.
+	withFields []Field
+	// redactor is set by WithRedact and masks the values of placeholders.
+	redactor *cores.Redactor
+
+	development bool
+	exitFunc    func(code int)
+
+	// closers close the cores added with WrapCore, innermost first, and
+	// closeSinks the sinks opened by Config.Build.
+	closers    []func() error
+	closeSinks func()
+
+	// recording is set by WithFlightRecorder, the core then decides which
+	// levels are written.
+	recording bool
 }
 
 func NewLogger(core Core, opts ...Option) *Logger {
+	level := NewAtomicLevelAt(LevelDebug)
 	l := &Logger{
-		core: core,
+		core:  core,
+		level: &level,
 	}
 	return l.WithOptions(opts...)
 }
@@ -65,11 +101,19 @@ func (l *Logger) With(args ...any) *Logger {
 	if len(args) == 0 {
 		return l
 	}
+	fields := argsToFields(args)
 	log := l.clone()
-	log.core = l.core.With(argsToFields(args))
+	log.core = l.core.With(fields)
+	log.addWithFields(fields)
 	return log
 }
 
+func (l *Logger) addWithFields(fields []Field) {
+	if l.msgPlaceholders {
+		l.withFields = append(l.withFields[:len(l.withFields):len(l.withFields)], fields...)
+	}
+}
+
 func (l *Logger) WithOptions(opts ...Option) *Logger {
 	if len(opts) == 0 {
 		return l
@@ -99,26 +143,97 @@ func (l *Logger) WithFormatDisable() *Logger {
 }
 
 func (l *Logger) WithContext(ctx context.Context) *Logger {
-	if ctx == nil || len(l.contextHandlers) == 0 {
+	if ctx == nil || (len(l.contextHandlers) == 0 && !l.recording) {
 		return l
 	}
 
-	record := NewRecordWithCapacity(len(l.contextHandlers))
-	for _, handler := range l.contextHandlers {
-		handler(ctx, record)
-	}
+	record := NewRecordWithCapacity(len(l.contextHandlers) + 1)
+	l.handleContext(ctx, record)
 
 	log := l.clone()
 	log.core = l.core.With(record.Fields())
+	log.addWithFields(record.Fields())
+
+	return log
+}
 
+// Named returns a logger whose name is the current name and name joined by
+// the separator set with WithNameSeparator, a dot by default.
+func (l *Logger) Named(name string) *Logger {
+	if name == "" {
+		return l
+	}
+	log := l.clone()
+	if l.name == "" {
+		log.name = name
+	} else {
+		sep := l.nameSeparator
+		if sep == "" {
+			sep = DefaultNameSeparator
+		}
+		log.name = l.name + sep + name
+	}
 	return log
 }
 
+// levelEnabled reports whether lvl passes the level of the logger, using the
+// override for the logger name if there is one.
+func (l *Logger) levelEnabled(lvl Level) bool {
+	if l.recording {
+		return true
+	}
+	return levelEnabled(l.level, l.nameLevels, l.name, lvl)
+}
+
+func levelEnabled(level *AtomicLevel, nameLevels *NameLevels, name string, lvl Level) bool {
+	if nameLevels != nil {
+		if min, ok := nameLevels.Level(name); ok {
+			return lvl >= min
+		}
+	}
+	return level == nil || level.Enabled(lvl)
+}
+
+// Enabled reports whether an entry at lvl would be passed to the core. With
+// WithFlightRecorder, that is every level the core enables.
 func (l *Logger) Enabled(lvl Level) bool {
-	return l.core.Enabled(lvl)
+	return l.levelEnabled(lvl) && l.core.Enabled(lvl)
+}
+
+// Level returns the minimum enabled level of the logger.
+func (l *Logger) Level() Level {
+	return zapcore.LevelOf(LevelEnablerFunc(l.Enabled))
+}
+
+// SetLevel changes the level of the logger at runtime. The change is seen by
+// every logger sharing the same AtomicLevel, including those derived from l.
+// The core may still filter out levels below its own minimum.
+func (l *Logger) SetLevel(lvl Level) {
+	if l.level != nil {
+		l.level.SetLevel(lvl)
+	}
+}
+
+// NameLevels returns the per-name level overrides of the logger, which can be
+// changed at runtime. It is nil unless set with WithNameLevels or by Config.
+func (l *Logger) NameLevels() *NameLevels {
+	return l.nameLevels
+}
+
+// LevelHandler returns an http.Handler that reports the level on GET and
+// changes it on PUT, accepting {"level":"debug"} or level=debug.
+func (l *Logger) LevelHandler() http.Handler {
+	if l.level == nil {
+		return http.NotFoundHandler()
+	}
+	return *l.level
 }
 
 func (l *Logger) check(lvl Level, msg string) (ce *zapcore.CheckedEntry) {
+	if !l.levelEnabled(lvl) {
+		return nil
+	}
+
 	ent := zapcore.Entry{
 		LoggerName: l.name,
 		Time:       time.Now(),
@@ -172,32 +287,128 @@ func (l *Logger) check(lvl Level, msg string) (ce *zapcore.CheckedEntry) {
 }
 
 func (l *Logger) log(ctx context.Context, lvl Level, msg string, args ...any) {
-	if !l.core.Enabled(lvl) {
+	if lvl < LevelDPanic && !l.Enabled(lvl) {
 		return
 	}
 
 	msg, msgFormatted := l.formatMessage(msg, args)
+	defer func() {
+		l.terminate(lvl, msg)
+	}()
+
 	ce := l.check(lvl, msg)
 	if ce == nil {
 		return
 	}
 
 	var fields []Field
-	capacity := len(l.contextHandlers)
 	if !msgFormatted {
 		fields = argsToFields(args)
-		capacity += len(fields)
+	}
+	if !l.msgPlaceholders {
+		l.write(ctx, ce, fields)
+		return
 	}
 
-	record := NewRecordWithCapacity(capacity)
-	if ctx != nil && len(l.contextHandlers) > 0 {
-		for _, handler := range l.contextHandlers {
-			handler(ctx, record)
-		}
+	record := l.newRecord(ctx, fields, 1)
+	if rendered, ok := l.renderPlaceholders(msg, record.Fields()); ok {
+		record.AddFields(String(l.templateKey(), msg))
+		msg = rendered
+		ce.Message = rendered
+	}
+	ce.Write(record.Fields()...)
+}
+
+// logf always formats the message, unlike log with WithFormat.
+func (l *Logger) logf(ctx context.Context, lvl Level, format string, args ...any) {
+	if lvl < LevelDPanic && !l.Enabled(lvl) {
+		return
+	}
+
+	msg := fmt.Sprintf(format, args...)
+	defer l.terminate(lvl, msg)
+
+	ce := l.check(lvl, msg)
+	if ce == nil {
+		return
+	}
+
+	var fields []Field
+	if l.msgTemplateKey != "" {
+		fields = []Field{String(l.msgTemplateKey, format)}
+	}
+	l.write(ctx, ce, fields)
+}
+
+// write writes ce with the fields of the context handlers followed by fields.
+func (l *Logger) write(ctx context.Context, ce *zapcore.CheckedEntry, fields []Field) {
+	ce.Write(l.newRecord(ctx, fields, 0).Fields()...)
+}
+
+// newRecord returns a record of the fields of the context handlers followed
+// by fields, with room for extra more.
+func (l *Logger) newRecord(ctx context.Context, fields []Field, extra int) *Record {
+	record := NewRecordWithCapacity(len(l.contextHandlers) + 1 + len(fields) + extra)
+	if ctx != nil {
+		l.handleContext(ctx, record)
 	}
 	record.AddFields(fields...)
+	return record
+}
 
-	ce.Write(record.Fields()...)
+// handleContext adds the fields of the context handlers to record, followed
+// by the buffer of NewFlightRecorderContext if the logger has a flight
+// recorder. The latter is not a context handler, so that WithContextHandlers
+// does not drop it.
+func (l *Logger) handleContext(ctx context.Context, record *Record) {
+	for _, handler := range l.contextHandlers {
+		handler(ctx, record)
+	}
+	if l.recording {
+		if r := cores.FlightRecorderFromContext(ctx); r != nil {
+			record.AddFields(cores.FlightRecorderField(r))
+		}
+	}
+}
+
+// renderPlaceholders fills the placeholders of msg from fields and the fields
+// added with With, masked by the redactor of WithRedact if any.
+func (l *Logger) renderPlaceholders(msg string, fields []Field) (string, bool) {
+	withFields := l.withFields
+	if l.redactor != nil && hasPlaceholders(msg) {
+		fields = l.redactor.Redact(fields)
+		withFields = l.redactor.Redact(withFields)
+	}
+	return renderPlaceholders(msg, fields, withFields)
+}
+
+func (l *Logger) templateKey() string {
+	if l.msgTemplateKey != "" {
+		return l.msgTemplateKey
+	}
+	return DefaultMsgTemplateKey
+}
+
+// terminate applies the side effect of the DPanic, Panic and Fatal levels
+// once the entry has been written.
+func (l *Logger) terminate(lvl Level, msg string) {
+	switch lvl {
+	case LevelDPanic:
+		if l.development {
+			_ = l.Sync()
+			panic(msg)
+		}
+	case LevelPanic:
+		_ = l.Sync()
+		panic(msg)
+	case LevelFatal:
+		_ = l.Sync()
+		exit := l.exitFunc
+		if exit == nil {
+			exit = os.Exit
+		}
+		exit(1)
+	}
 }
 
 func (l *Logger) LogContext(ctx context.Context, lvl Level, msg string, args ...any) {
@@ -240,10 +451,123 @@ func (l *Logger) ErrorContext(ctx context.Context, msg string, args ...any) {
 	l.log(ctx, LevelError, msg, args...)
 }
 
+// DPanic logs at LevelDPanic. In development mode the logger then panics.
+func (l *Logger) DPanic(msg string, args ...any) {
+	l.log(nil, LevelDPanic, msg, args...)
+}
+
+func (l *Logger) DPanicContext(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, LevelDPanic, msg, args...)
+}
+
+// Panic logs at LevelPanic and then panics with the rendered message.
+func (l *Logger) Panic(msg string, args ...any) {
+	l.log(nil, LevelPanic, msg, args...)
+}
+
+func (l *Logger) PanicContext(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, LevelPanic, msg, args...)
+}
+
+// Fatal logs at LevelFatal, flushes the core and then exits the process.
+func (l *Logger) Fatal(msg string, args ...any) {
+	l.log(nil, LevelFatal, msg, args...)
+}
+
+func (l *Logger) FatalContext(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, LevelFatal, msg, args...)
+}
+
+func (l *Logger) LogfContext(ctx context.Context, lvl Level, format string, args ...any) {
+	l.logf(ctx, lvl, format, args...)
+}
+
+// Logf logs the message formatted by fmt.Sprintf, whether or not WithFormat
+// is enabled. The format string is recorded if WithMsgTemplateKey is set.
+func (l *Logger) Logf(lvl Level, format string, args ...any) {
+	l.logf(nil, lvl, format, args...)
+}
+
+func (l *Logger) Debugf(format string, args ...any) {
+	l.logf(nil, LevelDebug, format, args...)
+}
+
+func (l *Logger) DebugfContext(ctx context.Context, format string, args ...any) {
+	l.logf(ctx, LevelDebug, format, args...)
+}
+
+func (l *Logger) Infof(format string, args ...any) {
+	l.logf(nil, LevelInfo, format, args...)
+}
+
+func (l *Logger) InfofContext(ctx context.Context, format string, args ...any) {
+	l.logf(ctx, LevelInfo, format, args...)
+}
+
+func (l *Logger) Warnf(format string, args ...any) {
+	l.logf(nil, LevelWarn, format, args...)
+}
+
+func (l *Logger) WarnfContext(ctx context.Context, format string, args ...any) {
+	l.logf(ctx, LevelWarn, format, args...)
+}
+
+func (l *Logger) Errorf(format string, args ...any) {
+	l.logf(nil, LevelError, format, args...)
+}
+
+func (l *Logger) ErrorfContext(ctx context.Context, format string, args ...any) {
+	l.logf(ctx, LevelError, format, args...)
+}
+
+func (l *Logger) DPanicf(format string, args ...any) {
+	l.logf(nil, LevelDPanic, format, args...)
+}
+
+func (l *Logger) DPanicfContext(ctx context.Context, format string, args ...any) {
+	l.logf(ctx, LevelDPanic, format, args...)
+}
+
+func (l *Logger) Panicf(format string, args ...any) {
+	l.logf(nil, LevelPanic, format, args...)
+}
+
+func (l *Logger) PanicfContext(ctx context.Context, format string, args ...any) {
+	l.logf(ctx, LevelPanic, format, args...)
+}
+
+func (l *Logger) Fatalf(format string, args ...any) {
+	l.logf(nil, LevelFatal, format, args...)
+}
+
+func (l *Logger) FatalfContext(ctx context.Context, format string, args ...any) {
+	l.logf(ctx, LevelFatal, format, args...)
+}
+
 func (l *Logger) Sync() error {
 	return l.core.Sync()
 }
 
+// Close closes the cores that have a Close method, outermost first, which
+// writes what they still hold and stops their goroutines, syncs the core and
+// closes the sinks opened by Config.Build. The logger and the loggers derived
+// from it share these and must not be used afterwards.
+func (l *Logger) Close() error {
+	var err error
+	for i := len(l.closers) - 1; i >= 0; i-- {
+		if cerr := l.closers[i](); cerr != nil {
+			err = cerr
+		}
+	}
+	if serr := l.core.Sync(); serr != nil {
+		err = serr
+	}
+	if l.closeSinks != nil {
+		l.closeSinks()
+	}
+	return err
+}
+
 func countPercent(s string) int {
 	count := 0
 	for i := 0; i < len(s); i++ {
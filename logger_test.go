@@ -3,6 +3,10 @@ package glog
 import (
 	"context"
 	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -380,3 +384,176 @@ func TestLogger_log(t *testing.T) {
 		assert.True(t, core.entries[0].Caller.Defined, "Expected caller information to be added")
 	})
 }
+
+func TestLogger_logf(t *testing.T) {
+	core := &mockCore{enabled: true}
+	logger := NewLogger(core, AddCaller(), WithContextHandlers(BuildContextHandler("key")))
+
+	logger.Infof("user %s has %d items", "bob", 3)
+	if assert.Len(t, core.entries, 1, "Expected one log entry") {
+		assert.Equal(t, "user bob has 3 items", core.entries[0].Message, "Expected the message to be formatted without WithFormat")
+		assert.Equal(t, "logger_test.go", filepath.Base(core.entries[0].Caller.File), "Expected the caller of Infof")
+	}
+	assert.Empty(t, core.fields, "Expected no template field by default")
+
+	core.reset()
+	core.enabled = true
+	logger = logger.WithOptions(AddMsgTemplate())
+	ctx := context.WithValue(context.Background(), "key", "value")
+	logger.WarnfContext(ctx, "took %v", String("k", "v"))
+	if assert.Len(t, core.entries, 1) {
+		assert.Equal(t, LevelWarn, core.entries[0].Level)
+		assert.Contains(t, core.entries[0].Message, "took {k", "Expected fields to be formatted as values")
+	}
+	assert.Equal(t, []Field{Any("key", "value"), String("msg_template", "took %v")}, core.fields)
+
+	core.reset()
+	logger = NewLogger(core, WithLevel(NewAtomicLevelAt(LevelInfo)), WithMsgTemplateKey("tpl"))
+	logger.Debugf("disabled %d", 1)
+	assert.Empty(t, core.entries)
+
+	core.enabled = true
+	logger.Logf(LevelError, "%d%%", 100)
+	if assert.Len(t, core.entries, 1) {
+		assert.Equal(t, "100%", core.entries[0].Message)
+	}
+	assert.Equal(t, []Field{String("tpl", "%d%%")}, core.fields)
+
+	assert.PanicsWithValue(t, "panic 1", func() {
+		logger.Panicf("panic %d", 1)
+	})
+}
+
+func TestLogger_Panic(t *testing.T) {
+	core := &mockCore{enabled: true}
+	logger := NewLogger(core)
+
+	assert.PanicsWithValue(t, "test panic", func() {
+		logger.Panic("test panic")
+	}, "Expected Panic to panic with the message")
+	assert.Len(t, core.entries, 1, "Expected entry to be written before panicking")
+	assert.Equal(t, LevelPanic, core.entries[0].Level, "Expected entry level to be panic")
+
+	core.reset()
+	logger = logger.WithFormatEnable()
+	assert.PanicsWithValue(t, "Hello world", func() {
+		logger.PanicContext(context.Background(), "Hello %s", "world")
+	}, "Expected Panic to panic with the formatted message")
+	assert.Len(t, core.entries, 0, "Expected no entry when core is disabled")
+}
+
+func TestLogger_DPanic(t *testing.T) {
+	core := &mockCore{enabled: true}
+	logger := NewLogger(core)
+
+	assert.NotPanics(t, func() {
+		logger.DPanic("test dpanic")
+	}, "Expected DPanic not to panic outside development mode")
+	assert.Len(t, core.entries, 1, "Expected one log entry")
+
+	logger = logger.WithOptions(Development())
+	assert.PanicsWithValue(t, "test dpanic", func() {
+		logger.DPanicContext(context.Background(), "test dpanic")
+	}, "Expected DPanic to panic in development mode")
+	assert.Len(t, core.entries, 2, "Expected entry to be written before panicking")
+}
+
+func TestLogger_Fatal(t *testing.T) {
+	core := &mockCore{enabled: true}
+	exitCode := -1
+	logger := NewLogger(core, WithExitFunc(func(code int) {
+		exitCode = code
+	}))
+
+	logger.Fatal("test fatal", "key", "value")
+	assert.Equal(t, 1, exitCode, "Expected Fatal to exit with code 1")
+	assert.Len(t, core.entries, 1, "Expected one log entry")
+	assert.Equal(t, LevelFatal, core.entries[0].Level, "Expected entry level to be fatal")
+	assert.Contains(t, core.fields, String("key", "value"), "Expected fields to contain 'key: value'")
+
+	core.reset()
+	exitCode = -1
+	logger.FatalContext(context.Background(), "test fatal")
+	assert.Equal(t, 1, exitCode, "Expected Fatal to exit even when core is disabled")
+}
+
+func TestLogger_SetLevel(t *testing.T) {
+	core := &mockCore{enabled: true}
+	logger := NewLogger(core)
+	child := logger.With("key", "value")
+
+	assert.Equal(t, LevelDebug, logger.Level(), "Expected default level to be debug")
+
+	logger.SetLevel(LevelWarn)
+	assert.Equal(t, LevelWarn, logger.Level(), "Expected level to be warn")
+	assert.Equal(t, LevelWarn, child.Level(), "Expected derived logger to follow the level")
+	assert.False(t, child.Enabled(LevelInfo), "Expected info to be disabled")
+
+	child.Info("skipped")
+	child.Warn("written")
+	assert.Len(t, core.entries, 1, "Expected only the warn entry to be written")
+	assert.Equal(t, "written", core.entries[0].Message)
+
+	lvl := NewAtomicLevelAt(LevelError)
+	other := logger.WithOptions(WithLevel(lvl))
+	assert.Equal(t, LevelError, other.Level(), "Expected WithLevel to replace the level")
+	assert.Equal(t, LevelWarn, logger.Level(), "Expected original logger to be unchanged")
+}
+
+func TestLogger_LevelHandler(t *testing.T) {
+	logger := NewLogger(&mockCore{enabled: true})
+	handler := logger.LevelHandler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/level", nil))
+	assert.JSONEq(t, `{"level":"debug"}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/level", strings.NewReader(`{"level":"warn"}`)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, LevelWarn, logger.Level(), "Expected JSON PUT to change the level")
+
+	req := httptest.NewRequest(http.MethodPut, "/level", strings.NewReader("level=error"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, LevelError, logger.Level(), "Expected form PUT to change the level")
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/level", strings.NewReader(`{"level":"bad"}`)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestLogger_Named(t *testing.T) {
+	logger := NewLogger(&mockCore{enabled: true})
+
+	assert.Equal(t, "db", logger.Named("db").name)
+	assert.Equal(t, "db.pool", logger.Named("db").Named("pool").name)
+	assert.Equal(t, logger, logger.Named(""), "Expected empty name to return the same logger")
+
+	logger = NewLogger(&mockCore{enabled: true}, WithName("app"), WithNameSeparator("/"))
+	assert.Equal(t, "app/db/pool", logger.Named("db").Named("pool").name, "Expected the configured separator")
+}
+
+func TestLogger_NameLevels(t *testing.T) {
+	core := &mockCore{enabled: true}
+	nameLevels, err := NewNameLevels(map[string]Level{"db.*": LevelWarn})
+	assert.NoError(t, err)
+	logger := NewLogger(core, WithNameLevels(nameLevels))
+	logger.SetLevel(LevelInfo)
+	db := logger.Named("db").Named("query")
+
+	assert.Equal(t, nameLevels, logger.NameLevels())
+	assert.False(t, db.Enabled(LevelInfo), "Expected name override to disable info")
+	assert.True(t, logger.Enabled(LevelInfo), "Expected other loggers to keep their level")
+
+	db.Info("skipped")
+	db.Warn("written")
+	assert.Len(t, core.entries, 1, "Expected only the warn entry to be written")
+	assert.Equal(t, "db.query", core.entries[0].LoggerName)
+
+	assert.NoError(t, nameLevels.Set("db.query", LevelDebug))
+	assert.True(t, db.Enabled(LevelDebug), "Expected runtime change to lower the level below the logger level")
+	assert.False(t, logger.Enabled(LevelDebug))
+}
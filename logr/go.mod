@@ -0,0 +1,20 @@
+module github.com/ace-zhaoy/glog/logr
+
+go 1.18
+
+require (
+	github.com/ace-zhaoy/glog v0.0.0-00010101000000-000000000000
+	github.com/go-logr/logr v1.2.3
+	github.com/stretchr/testify v1.8.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	go.uber.org/atomic v1.7.0 // indirect
+	go.uber.org/multierr v1.6.0 // indirect
+	go.uber.org/zap v1.23.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/ace-zhaoy/glog => ../
@@ -0,0 +1,122 @@
+package logr
+
+import (
+	"github.com/ace-zhaoy/glog"
+	"github.com/go-logr/logr"
+)
+
+const DefaultErrorKey = "error"
+
+type config struct {
+	levelFunc  func(v int) glog.Level
+	errorKey   string
+	errorStack bool
+}
+
+type Option func(*config)
+
+// WithLevelFunc sets how V-levels map to glog levels, DefaultLevel by default.
+func WithLevelFunc(f func(v int) glog.Level) Option {
+	return func(c *config) {
+		c.levelFunc = f
+	}
+}
+
+// WithErrorKey sets the key of the error passed to Error, DefaultErrorKey by default.
+func WithErrorKey(key string) Option {
+	return func(c *config) {
+		c.errorKey = key
+	}
+}
+
+// WithErrorStack sets whether Error adds a stack trace to the entry, true by default.
+func WithErrorStack(enabled bool) Option {
+	return func(c *config) {
+		c.errorStack = enabled
+	}
+}
+
+// DefaultLevel maps V(0) to glog.LevelInfo and more verbose levels to
+// glog.LevelDebug.
+func DefaultLevel(v int) glog.Level {
+	if v <= 0 {
+		return glog.LevelInfo
+	}
+	return glog.LevelDebug
+}
+
+// LogSink is a logr.LogSink writing to a glog.Logger. The names given to
+// WithName are joined like glog.Logger.Named.
+type LogSink struct {
+	l   *glog.Logger
+	cfg *config
+}
+
+var (
+	_ logr.LogSink                = (*LogSink)(nil)
+	_ logr.CallDepthLogSink       = (*LogSink)(nil)
+	_ logr.CallStackHelperLogSink = (*LogSink)(nil)
+)
+
+func NewLogSink(l *glog.Logger, opts ...Option) *LogSink {
+	cfg := &config{
+		levelFunc:  DefaultLevel,
+		errorKey:   DefaultErrorKey,
+		errorStack: true,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return &LogSink{
+		// Skip the method of the sink, logr adds its own frames in Init.
+		l:   l.WithOptions(glog.AddCallerSkip(1)),
+		cfg: cfg,
+	}
+}
+
+// NewLogger returns a logr.Logger writing to l.
+func NewLogger(l *glog.Logger, opts ...Option) logr.Logger {
+	return logr.New(NewLogSink(l, opts...))
+}
+
+func (s *LogSink) Init(info logr.RuntimeInfo) {
+	s.l = s.l.WithOptions(glog.AddCallerSkip(info.CallDepth))
+}
+
+func (s *LogSink) Enabled(level int) bool {
+	return s.l.Enabled(s.cfg.levelFunc(level))
+}
+
+func (s *LogSink) Info(level int, msg string, keysAndValues ...any) {
+	s.l.Log(s.cfg.levelFunc(level), msg, keysAndValues...)
+}
+
+// Error logs at glog.LevelError, whatever the V-level of the logger.
+func (s *LogSink) Error(err error, msg string, keysAndValues ...any) {
+	l := s.l
+	if s.cfg.errorStack {
+		l = l.WithOptions(glog.WithStack(glog.LevelError))
+	}
+	if err != nil {
+		keysAndValues = append([]any{glog.NamedErr(s.cfg.errorKey, err)}, keysAndValues...)
+	}
+	l.Log(glog.LevelError, msg, keysAndValues...)
+}
+
+func (s *LogSink) WithValues(keysAndValues ...any) logr.LogSink {
+	return &LogSink{l: s.l.With(keysAndValues...), cfg: s.cfg}
+}
+
+func (s *LogSink) WithName(name string) logr.LogSink {
+	return &LogSink{l: s.l.Named(name), cfg: s.cfg}
+}
+
+func (s *LogSink) WithCallDepth(depth int) logr.LogSink {
+	return &LogSink{l: s.l.WithOptions(glog.AddCallerSkip(depth)), cfg: s.cfg}
+}
+
+// GetCallStackHelper returns a no-op, since glog.Logger resolves the caller
+// by depth rather than by marking helper functions.
+func (s *LogSink) GetCallStackHelper() func() {
+	return func() {}
+}
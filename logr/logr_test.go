@@ -0,0 +1,101 @@
+package logr
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ace-zhaoy/glog"
+	"github.com/ace-zhaoy/glog/glogtest"
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogSink_levels(t *testing.T) {
+	l, logs := glogtest.NewObservedAt(glog.LevelInfo)
+	logger := NewLogger(l)
+
+	logger.Info("info", "user_id", 42)
+	logger.V(1).Info("debug")
+	assert.False(t, logger.V(1).Enabled(), "Expected V(1) to map to debug")
+
+	entries := logs.All()
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, glog.LevelInfo, entries[0].Level)
+		assert.Equal(t, map[string]any{"user_id": int64(42)}, entries[0].ContextMap())
+	}
+
+	l, logs = glogtest.NewObserved()
+	logger = NewLogger(l, WithLevelFunc(func(v int) glog.Level {
+		if v >= 2 {
+			return glog.LevelDebug
+		}
+		return glog.LevelInfo
+	}))
+	logger.V(1).Info("info")
+	logger.V(2).Info("debug")
+	assert.Equal(t, []string{"info"}, logs.FilterLevel(glog.LevelInfo).Messages())
+	assert.Equal(t, []string{"debug"}, logs.FilterLevel(glog.LevelDebug).Messages())
+}
+
+func TestLogSink_WithNameAndValues(t *testing.T) {
+	l, logs := glogtest.NewObserved()
+	logger := NewLogger(l.Named("operator")).WithName("controller").WithValues("kind", "Pod")
+
+	logger.Info("reconciled", "name", "web")
+	entries := logs.All()
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, "operator.controller", entries[0].LoggerName)
+		assert.Equal(t, map[string]any{"kind": "Pod", "name": "web"}, entries[0].ContextMap())
+	}
+}
+
+func TestLogSink_Error(t *testing.T) {
+	l, logs := glogtest.NewObserved()
+	logger := NewLogger(l)
+
+	logger.V(1).Error(errors.New("boom"), "reconcile failed", "name", "web")
+	entries := logs.All()
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, glog.LevelError, entries[0].Level)
+		assert.Equal(t, map[string]any{"error": map[string]any{"msg": "boom"}, "name": "web"}, entries[0].ContextMap())
+		assert.True(t, strings.HasPrefix(entries[0].Stack, "github.com/ace-zhaoy/glog/logr.TestLogSink_Error"), "Expected the stack to start at the caller: %s", entries[0].Stack)
+	}
+
+	l, logs = glogtest.NewObserved()
+	NewLogger(l, WithErrorKey("err"), WithErrorStack(false)).Error(nil, "no error")
+	entries = logs.All()
+	if assert.Len(t, entries, 1) {
+		assert.Empty(t, entries[0].Context, "Expected no field for a nil error")
+		assert.Empty(t, entries[0].Stack)
+	}
+
+	NewLogger(l, WithErrorKey("err")).Error(errors.New("boom"), "custom key")
+	assert.Len(t, logs.FilterFieldKey("err").All(), 1)
+}
+
+func helper(logger logr.Logger) {
+	logger.WithCallDepth(1).Info("from helper")
+}
+
+func TestLogSink_caller(t *testing.T) {
+	l, logs := glogtest.NewObserved(glog.AddCaller())
+	logger := NewLogger(l)
+
+	logger.Info("direct")
+	helper(logger)
+	logger.WithName("named").Error(errors.New("boom"), "error")
+
+	for _, e := range logs.All() {
+		assert.Equal(t, "logr_test.go", filepath.Base(e.Caller.File), "Unexpected caller for %q: %s", e.Message, e.Caller)
+	}
+	callers := logs.FilterMessage("from helper").All()
+	if assert.Len(t, callers, 1) {
+		assert.Contains(t, callers[0].Caller.Function, "TestLogSink_caller", "Expected the caller of helper")
+	}
+
+	var sink logr.LogSink = NewLogSink(l)
+	_, ok := sink.(logr.CallStackHelperLogSink)
+	assert.True(t, ok)
+}
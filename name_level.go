@@ -0,0 +1,175 @@
+package glog
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// NameLevels maps logger-name patterns to minimum levels. A pattern is either
+// an exact name ("http.client"), a prefix ending in ".*" ("db.*" matches
+// "db.query" and "db.pool.conn") or a glob understood by path.Match.
+// An exact match wins over a prefix, the longest prefix wins over a shorter
+// one and prefixes win over globs. NameLevels is safe for concurrent use and
+// may be changed while loggers use it.
+type NameLevels struct {
+	mu       sync.Mutex
+	patterns map[string]Level
+	rules    atomic.Value // *nameLevelRules
+}
+
+type nameLevelRule struct {
+	pattern string
+	prefix  string
+	level   Level
+}
+
+type nameLevelRules struct {
+	exact    map[string]Level
+	prefixes []nameLevelRule
+	globs    []nameLevelRule
+	min      Level
+}
+
+func NewNameLevels(levels map[string]Level) (*NameLevels, error) {
+	n := &NameLevels{}
+	if err := n.Reset(levels); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func validateNamePattern(pattern string) error {
+	if pattern == "" {
+		return fmt.Errorf("empty logger name pattern")
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid logger name pattern %q: %w", pattern, err)
+	}
+	return nil
+}
+
+// Set sets the minimum level for pattern.
+func (n *NameLevels) Set(pattern string, lvl Level) error {
+	if err := validateNamePattern(pattern); err != nil {
+		return err
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.patterns == nil {
+		n.patterns = make(map[string]Level)
+	}
+	n.patterns[pattern] = lvl
+	n.rebuild()
+	return nil
+}
+
+// Delete removes the override for pattern.
+func (n *NameLevels) Delete(pattern string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	delete(n.patterns, pattern)
+	n.rebuild()
+}
+
+// Reset replaces all overrides with levels.
+func (n *NameLevels) Reset(levels map[string]Level) error {
+	patterns := make(map[string]Level, len(levels))
+	for pattern, lvl := range levels {
+		if err := validateNamePattern(pattern); err != nil {
+			return err
+		}
+		patterns[pattern] = lvl
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.patterns = patterns
+	n.rebuild()
+	return nil
+}
+
+// Levels returns a copy of the configured overrides.
+func (n *NameLevels) Levels() map[string]Level {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	levels := make(map[string]Level, len(n.patterns))
+	for pattern, lvl := range n.patterns {
+		levels[pattern] = lvl
+	}
+	return levels
+}
+
+func (n *NameLevels) rebuild() {
+	rules := &nameLevelRules{
+		exact: make(map[string]Level),
+		min:   LevelFatal + 1,
+	}
+	for pattern, lvl := range n.patterns {
+		if lvl < rules.min {
+			rules.min = lvl
+		}
+		switch {
+		case strings.HasSuffix(pattern, ".*") && !strings.ContainsAny(pattern[:len(pattern)-2], "*?[\\"):
+			rules.prefixes = append(rules.prefixes, nameLevelRule{pattern: pattern, prefix: pattern[:len(pattern)-1], level: lvl})
+		case strings.ContainsAny(pattern, "*?[\\"):
+			rules.globs = append(rules.globs, nameLevelRule{pattern: pattern, level: lvl})
+		default:
+			rules.exact[pattern] = lvl
+		}
+	}
+	sort.Slice(rules.prefixes, func(i, j int) bool {
+		return len(rules.prefixes[i].prefix) > len(rules.prefixes[j].prefix)
+	})
+	sort.Slice(rules.globs, func(i, j int) bool {
+		if len(rules.globs[i].pattern) != len(rules.globs[j].pattern) {
+			return len(rules.globs[i].pattern) > len(rules.globs[j].pattern)
+		}
+		return rules.globs[i].pattern < rules.globs[j].pattern
+	})
+	n.rules.Store(rules)
+}
+
+func (n *NameLevels) load() *nameLevelRules {
+	rules, _ := n.rules.Load().(*nameLevelRules)
+	return rules
+}
+
+// Level returns the minimum level configured for the logger name.
+func (n *NameLevels) Level(name string) (Level, bool) {
+	rules := n.load()
+	if rules == nil || len(rules.exact)+len(rules.prefixes)+len(rules.globs) == 0 {
+		return 0, false
+	}
+
+	if lvl, ok := rules.exact[name]; ok {
+		return lvl, true
+	}
+	for _, rule := range rules.prefixes {
+		if strings.HasPrefix(name, rule.prefix) {
+			return rule.level, true
+		}
+	}
+	for _, rule := range rules.globs {
+		if ok, _ := path.Match(rule.pattern, name); ok {
+			return rule.level, true
+		}
+	}
+	return 0, false
+}
+
+// minLevel returns the lowest level of all overrides, or false if there are none.
+func (n *NameLevels) minLevel() (Level, bool) {
+	rules := n.load()
+	if rules == nil || rules.min > LevelFatal {
+		return 0, false
+	}
+	return rules.min, true
+}
@@ -0,0 +1,65 @@
+package glog
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNameLevels_Level(t *testing.T) {
+	n, err := NewNameLevels(map[string]Level{
+		"db.*":        LevelWarn,
+		"db.pool.*":   LevelError,
+		"http.client": LevelDebug,
+		"*.cache":     LevelInfo,
+		"db.cache":    LevelDebug,
+	})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		want   Level
+		wantOK bool
+	}{
+		{name: "http.client", want: LevelDebug, wantOK: true},
+		{name: "db.query", want: LevelWarn, wantOK: true},
+		{name: "db.pool.conn", want: LevelError, wantOK: true},
+		{name: "db.cache", want: LevelDebug, wantOK: true},
+		{name: "redis.cache", want: LevelInfo, wantOK: true},
+		{name: "db", wantOK: false},
+		{name: "http", wantOK: false},
+		{name: "", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := n.Level(tt.name)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	minLevel, ok := n.minLevel()
+	assert.True(t, ok)
+	assert.Equal(t, LevelDebug, minLevel)
+}
+
+func TestNameLevels_SetAndDelete(t *testing.T) {
+	n, err := NewNameLevels(nil)
+	assert.NoError(t, err)
+	_, ok := n.minLevel()
+	assert.False(t, ok, "Expected no min level without overrides")
+
+	assert.NoError(t, n.Set("db.*", LevelWarn))
+	lvl, ok := n.Level("db.query")
+	assert.True(t, ok)
+	assert.Equal(t, LevelWarn, lvl)
+	assert.Equal(t, map[string]Level{"db.*": LevelWarn}, n.Levels())
+
+	n.Delete("db.*")
+	_, ok = n.Level("db.query")
+	assert.False(t, ok, "Expected override to be deleted")
+
+	assert.Error(t, n.Set("", LevelWarn), "Expected error for empty pattern")
+	assert.Error(t, n.Set("db.[", LevelWarn), "Expected error for malformed pattern")
+	_, err = NewNameLevels(map[string]Level{"[": LevelWarn})
+	assert.Error(t, err, "Expected error for malformed pattern")
+}
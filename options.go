@@ -1,5 +1,10 @@
 package glog
 
+import (
+	"github.com/ace-zhaoy/glog/cores"
+	"go.uber.org/zap/zapcore"
+)
+
 type Option interface {
 	apply(*Logger)
 }
@@ -10,9 +15,38 @@ func (f optionFunc) apply(log *Logger) {
 	f(log)
 }
 
+// WrapCore replaces the core with the one f returns. If it has a Close()
+// error method, as the cores of the Async, RateLimit and Dedup settings of
+// Config do, Logger.Close calls it.
 func WrapCore(f func(core Core) Core) Option {
 	return optionFunc(func(log *Logger) {
 		log.core = f(log.core)
+		if c, ok := log.core.(interface{ Close() error }); ok {
+			log.closers = append(log.closers[:len(log.closers):len(log.closers)], c.Close)
+		}
+	})
+}
+
+// withCloseSinks makes Logger.Close close the sinks of the core with f.
+func withCloseSinks(f func()) Option {
+	return optionFunc(func(log *Logger) {
+		log.closeSinks = f
+	})
+}
+
+// WithLevel makes the logger use lvl, so the level can be changed at runtime
+// through lvl or Logger.SetLevel.
+func WithLevel(lvl AtomicLevel) Option {
+	return optionFunc(func(l *Logger) {
+		l.level = &lvl
+	})
+}
+
+// WithNameLevels makes the logger use the minimum level configured in n for
+// its name instead of its own level.
+func WithNameLevels(n *NameLevels) Option {
+	return optionFunc(func(l *Logger) {
+		l.nameLevels = n
 	})
 }
 
@@ -22,6 +56,44 @@ func WithName(name string) Option {
 	})
 }
 
+// WithNameSeparator sets the separator Named joins names with. The prefix
+// patterns of NameLevels, such as "db.*", assume the default separator; use
+// globs with another one.
+func WithNameSeparator(sep string) Option {
+	return optionFunc(func(l *Logger) {
+		l.nameSeparator = sep
+	})
+}
+
+// WithMsgTemplateKey makes Debugf and the like record their format string in
+// a field with key. An empty key disables it.
+func WithMsgTemplateKey(key string) Option {
+	return optionFunc(func(l *Logger) {
+		l.msgTemplateKey = key
+	})
+}
+
+// AddMsgTemplate records format strings under DefaultMsgTemplateKey.
+func AddMsgTemplate() Option {
+	return WithMsgTemplateKey(DefaultMsgTemplateKey)
+}
+
+// WithMsgPlaceholders makes the key-value methods fill placeholders such as
+// {user_id} in the message from the fields of the entry, including those of
+// the context handlers, or else from the fields added afterwards with With,
+// WithContext and NewContext. Fields added to the core directly, such as the
+// InitialFields of Config, are not used. Values are masked by the redactor of
+// WithRedact first; a RedactCore added with WrapCore is unknown to the logger
+// and would leave secrets in the message. Placeholders without a field are
+// left intact and {{ and }} stand for { and }. The raw message of an entry
+// whose placeholders or escapes were replaced is recorded under the key of
+// WithMsgTemplateKey, DefaultMsgTemplateKey if unset.
+func WithMsgPlaceholders(enabled bool) Option {
+	return optionFunc(func(l *Logger) {
+		l.msgPlaceholders = enabled
+	})
+}
+
 func WithCaller(enabled bool) Option {
 	return optionFunc(func(log *Logger) {
 		log.addCaller = enabled
@@ -77,3 +149,55 @@ func WithFields(fields ...Field) Option {
 		l.core = l.core.With(fields)
 	})
 }
+
+// WithDevelopment puts the logger in development mode, which makes DPanic panic.
+func WithDevelopment(enabled bool) Option {
+	return optionFunc(func(l *Logger) {
+		l.development = enabled
+	})
+}
+
+func Development() Option {
+	return WithDevelopment(true)
+}
+
+// WithExitFunc replaces the function used by Fatal to exit the process.
+// It defaults to os.Exit.
+func WithExitFunc(exit func(code int)) Option {
+	return optionFunc(func(l *Logger) {
+		l.exitFunc = exit
+	})
+}
+
+// WithFlightRecorder wraps the core with a cores.FlightRecorderCore. The logger
+// then passes entries at every level to the core, which writes those enabled
+// by the level of the logger and buffers the rest until an entry at the
+// trigger level arrives. Buffered entries are replayed through Check of the
+// core, so the core should enable every level, leaving the level of the logger
+// to decide what is buffered. Apply it after WithLevel and WithNameLevels.
+// Contexts from NewFlightRecorderContext get a buffer of their own, entries
+// logged without one go to a buffer shared by the logger. Since every entry is
+// buffered, Logger.Enabled and Logger.Level report every level the core
+// enables, whatever the level of the logger, so guarding expensive debug work
+// with them no longer saves it.
+func WithFlightRecorder(cfg FlightRecorderConfig) Option {
+	return optionFunc(func(l *Logger) {
+		if cfg.Forward == nil {
+			level, nameLevels := l.level, l.nameLevels
+			cfg.Forward = func(ent zapcore.Entry) bool {
+				return levelEnabled(level, nameLevels, ent.LoggerName, ent.Level)
+			}
+		}
+		l.core = cores.NewFlightRecorderCore(l.core, cfg)
+		l.recording = true
+	})
+}
+
+// WithRedact wraps the core with a cores.RedactCore masking fields with r,
+// which also masks the values filled into placeholders by WithMsgPlaceholders.
+func WithRedact(r *cores.Redactor) Option {
+	return optionFunc(func(l *Logger) {
+		l.core = cores.NewRedactCore(l.core, r)
+		l.redactor = r
+	})
+}
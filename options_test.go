@@ -148,3 +148,47 @@ func TestWithFields(t *testing.T) {
 	option.apply(logger)
 	assert.NotNil(t, logger.core, "Expected core to be set with fields")
 }
+
+func TestWithDevelopment(t *testing.T) {
+	logger := &Logger{}
+	Development().apply(logger)
+	assert.True(t, logger.development, "Expected development mode to be enabled")
+
+	WithDevelopment(false).apply(logger)
+	assert.False(t, logger.development, "Expected development mode to be disabled")
+}
+
+func TestWithExitFunc(t *testing.T) {
+	logger := &Logger{}
+	called := false
+	WithExitFunc(func(int) { called = true }).apply(logger)
+	logger.exitFunc(1)
+	assert.True(t, called, "Expected exit func to be set")
+}
+
+func TestWithFlightRecorder(t *testing.T) {
+	core := &mockCore{enabled: true}
+	level := NewAtomicLevelAt(LevelInfo)
+	logger := NewLogger(core, WithLevel(level), WithFlightRecorder(FlightRecorderConfig{}))
+
+	assert.True(t, logger.Enabled(LevelDebug), "Expected debug to reach the recorder")
+	logger.Debug("debug")
+	logger.Info("info")
+	assert.Len(t, core.entries, 1, "Expected only info to be written")
+
+	ctx := NewFlightRecorderContext(context.Background())
+	logger.DebugContext(ctx, "request debug")
+	logger.Error("error")
+	assert.Len(t, core.entries, 3, "Expected the logger buffer to be flushed before error")
+	assert.Equal(t, "debug", core.entries[1].Message)
+	assert.Equal(t, "error", core.entries[2].Message)
+	assert.Contains(t, core.fields, Bool("flight_recorder", true), "Expected flushed entries to be marked")
+
+	logger.ErrorContext(ctx, "request error")
+	assert.Len(t, core.entries, 5, "Expected the request buffer to be flushed before request error")
+	assert.Equal(t, "request debug", core.entries[3].Message)
+
+	level.SetLevel(LevelDebug)
+	logger.Debug("forwarded")
+	assert.Len(t, core.entries, 6, "Expected debug to be written once the level allows it")
+}
@@ -0,0 +1,154 @@
+package otel
+
+import (
+	"fmt"
+
+	"github.com/ace-zhaoy/glog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	spanEventName   = "log"
+	severityKey     = "log.severity"
+	messageKey      = "log.message"
+	spanCarrierName = "glog.otel.span"
+)
+
+// spanCarrier travels as a skipped field from ContextHandler to SpanEventCore,
+// encoders ignore it.
+type spanCarrier struct {
+	span trace.Span
+	lvl  glog.LevelEnabler
+}
+
+func spanField(span trace.Span, lvl glog.LevelEnabler) glog.Field {
+	return glog.Field{Key: spanCarrierName, Type: zapcore.SkipType, Interface: spanCarrier{span: span, lvl: lvl}}
+}
+
+func findCarrier(fields []zapcore.Field) (spanCarrier, bool) {
+	for i := len(fields) - 1; i >= 0; i-- {
+		if fields[i].Type == zapcore.SkipType && fields[i].Key == spanCarrierName {
+			if c, ok := fields[i].Interface.(spanCarrier); ok {
+				return c, true
+			}
+		}
+	}
+	return spanCarrier{}, false
+}
+
+// SpanEventCore records entries as events on the span found in their fields,
+// which ContextHandler adds when WithSpanEvents is used. Entries still go to
+// the wrapped core unchanged.
+type SpanEventCore struct {
+	core zapcore.Core
+
+	carrier    spanCarrier
+	hasCarrier bool
+	fields     []zapcore.Field
+}
+
+var _ zapcore.Core = (*SpanEventCore)(nil)
+
+func NewSpanEventCore(core zapcore.Core) zapcore.Core {
+	return &SpanEventCore{core: core}
+}
+
+func (c *SpanEventCore) Enabled(lvl zapcore.Level) bool {
+	return c.core.Enabled(lvl)
+}
+
+func (c *SpanEventCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.core = c.core.With(fields)
+	clone.fields = append(c.fields[:len(c.fields):len(c.fields)], fields...)
+	if carrier, ok := findCarrier(fields); ok {
+		clone.carrier, clone.hasCarrier = carrier, true
+	}
+	return &clone
+}
+
+func (c *SpanEventCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	ce = c.core.Check(ent, ce)
+	return ce.AddCore(ent, spanEventWriter{c})
+}
+
+// Write only records the span event. Entries reach the wrapped core through Check.
+func (c *SpanEventCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	carrier, ok := findCarrier(fields)
+	if !ok {
+		carrier, ok = c.carrier, c.hasCarrier
+	}
+	if !ok || !carrier.lvl.Enabled(ent.Level) || !carrier.span.IsRecording() {
+		return nil
+	}
+
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range c.fields {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	attrs := make([]attribute.KeyValue, 0, len(enc.Fields)+2)
+	attrs = append(attrs,
+		attribute.String(severityKey, ent.Level.String()),
+		attribute.String(messageKey, ent.Message),
+	)
+	for k, v := range enc.Fields {
+		attrs = append(attrs, toAttribute(k, v))
+	}
+	carrier.span.AddEvent(spanEventName, trace.WithAttributes(attrs...), trace.WithTimestamp(ent.Time))
+	return nil
+}
+
+func (c *SpanEventCore) Sync() error {
+	return c.core.Sync()
+}
+
+// spanEventWriter is added to checked entries so that SpanEventCore sees the
+// entry without taking over the Check of the wrapped core.
+type spanEventWriter struct {
+	*SpanEventCore
+}
+
+func (w spanEventWriter) Sync() error {
+	return nil
+}
+
+func toAttribute(key string, v any) attribute.KeyValue {
+	switch val := v.(type) {
+	case string:
+		return attribute.String(key, val)
+	case bool:
+		return attribute.Bool(key, val)
+	case int64:
+		return attribute.Int64(key, val)
+	case int32:
+		return attribute.Int64(key, int64(val))
+	case int16:
+		return attribute.Int64(key, int64(val))
+	case int8:
+		return attribute.Int64(key, int64(val))
+	case int:
+		return attribute.Int(key, val)
+	case uint32:
+		return attribute.Int64(key, int64(val))
+	case uint16:
+		return attribute.Int64(key, int64(val))
+	case uint8:
+		return attribute.Int64(key, int64(val))
+	case float64:
+		return attribute.Float64(key, val)
+	case float32:
+		return attribute.Float64(key, float64(val))
+	case []string:
+		return attribute.StringSlice(key, val)
+	case fmt.Stringer:
+		return attribute.String(key, val.String())
+	default:
+		return attribute.String(key, fmt.Sprint(val))
+	}
+}
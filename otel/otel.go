@@ -0,0 +1,140 @@
+package otel
+
+import (
+	"context"
+
+	"github.com/ace-zhaoy/glog"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	DefaultTraceIDKey     = "trace_id"
+	DefaultSpanIDKey      = "span_id"
+	DefaultTraceFlagsKey  = "trace_flags"
+	DefaultTraceParentKey = "traceparent"
+)
+
+// Format selects how the span context is written.
+type Format int
+
+const (
+	// FormatHex writes the trace ID, span ID and trace flags as separate hex fields.
+	FormatHex Format = iota
+	// FormatW3C writes a single W3C traceparent field, e.g.
+	// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01.
+	FormatW3C
+)
+
+type config struct {
+	traceIDKey     string
+	spanIDKey      string
+	traceFlagsKey  string
+	traceParentKey string
+	format         Format
+	spanEvents     glog.LevelEnabler
+}
+
+type Option func(*config)
+
+// WithTraceIDKey sets the field key of the trace ID. An empty key omits the field.
+func WithTraceIDKey(key string) Option {
+	return func(c *config) {
+		c.traceIDKey = key
+	}
+}
+
+// WithSpanIDKey sets the field key of the span ID. An empty key omits the field.
+func WithSpanIDKey(key string) Option {
+	return func(c *config) {
+		c.spanIDKey = key
+	}
+}
+
+// WithTraceFlagsKey sets the field key of the trace flags. An empty key omits the field.
+func WithTraceFlagsKey(key string) Option {
+	return func(c *config) {
+		c.traceFlagsKey = key
+	}
+}
+
+// WithTraceParentKey sets the field key used by FormatW3C.
+func WithTraceParentKey(key string) Option {
+	return func(c *config) {
+		c.traceParentKey = key
+	}
+}
+
+func WithFormat(format Format) Option {
+	return func(c *config) {
+		c.format = format
+	}
+}
+
+// WithSpanEvents records entries enabled by lvl as events on the active span.
+// It needs the core to be wrapped with NewSpanEventCore, which Options does.
+func WithSpanEvents(lvl glog.LevelEnabler) Option {
+	return func(c *config) {
+		c.spanEvents = lvl
+	}
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{
+		traceIDKey:     DefaultTraceIDKey,
+		spanIDKey:      DefaultSpanIDKey,
+		traceFlagsKey:  DefaultTraceFlagsKey,
+		traceParentKey: DefaultTraceParentKey,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// ContextHandler returns a glog.ContextHandler that adds the span context of
+// the active span in ctx to the record.
+func ContextHandler(opts ...Option) glog.ContextHandler {
+	c := newConfig(opts)
+	return func(ctx context.Context, record *glog.Record) {
+		if ctx == nil {
+			return
+		}
+		span := trace.SpanFromContext(ctx)
+		sc := span.SpanContext()
+		if !sc.IsValid() {
+			return
+		}
+
+		switch c.format {
+		case FormatW3C:
+			if c.traceParentKey != "" {
+				record.AddFields(glog.String(c.traceParentKey, "00-"+sc.TraceID().String()+"-"+sc.SpanID().String()+"-"+sc.TraceFlags().String()))
+			}
+		default:
+			if c.traceIDKey != "" {
+				record.AddFields(glog.String(c.traceIDKey, sc.TraceID().String()))
+			}
+			if c.spanIDKey != "" {
+				record.AddFields(glog.String(c.spanIDKey, sc.SpanID().String()))
+			}
+			if c.traceFlagsKey != "" {
+				record.AddFields(glog.String(c.traceFlagsKey, sc.TraceFlags().String()))
+			}
+		}
+
+		if c.spanEvents != nil {
+			record.AddFields(spanField(span, c.spanEvents))
+		}
+	}
+}
+
+// Options returns the glog options that install ContextHandler and, when span
+// events are enabled, wrap the core with NewSpanEventCore.
+func Options(opts ...Option) []glog.Option {
+	c := newConfig(opts)
+	options := []glog.Option{glog.AddContextHandlers(ContextHandler(opts...))}
+	if c.spanEvents != nil {
+		options = append(options, glog.WrapCore(NewSpanEventCore))
+	}
+	return options
+}
@@ -0,0 +1,113 @@
+package otel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ace-zhaoy/glog"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap/zapcore"
+)
+
+func newTestLogger(buf *bytes.Buffer, opts ...Option) *glog.Logger {
+	encoder := zapcore.NewJSONEncoder(zapcore.EncoderConfig{MessageKey: "msg", LevelKey: "level", EncodeLevel: zapcore.LowercaseLevelEncoder})
+	core := zapcore.NewCore(encoder, zapcore.AddSync(buf), zapcore.DebugLevel)
+	return glog.NewLogger(core, Options(opts...)...)
+}
+
+func decode(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+	m := map[string]any{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &m), "Expected valid JSON output")
+	buf.Reset()
+	return m
+}
+
+func TestContextHandler(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	ctx, span := tp.Tracer("test").Start(context.Background(), "op")
+	defer span.End()
+	sc := span.SpanContext()
+
+	buf := &bytes.Buffer{}
+	newTestLogger(buf).InfoContext(ctx, "hello")
+	m := decode(t, buf)
+	assert.Equal(t, sc.TraceID().String(), m[DefaultTraceIDKey], "Expected trace id")
+	assert.Equal(t, sc.SpanID().String(), m[DefaultSpanIDKey], "Expected span id")
+	assert.Equal(t, "01", m[DefaultTraceFlagsKey], "Expected sampled trace flags")
+	assert.NotContains(t, m, spanCarrierName, "Expected no carrier field in output")
+
+	newTestLogger(buf, WithTraceIDKey("tid"), WithSpanIDKey("sid"), WithTraceFlagsKey("")).InfoContext(ctx, "hello")
+	m = decode(t, buf)
+	assert.Equal(t, sc.TraceID().String(), m["tid"], "Expected custom trace id key")
+	assert.Equal(t, sc.SpanID().String(), m["sid"], "Expected custom span id key")
+	assert.NotContains(t, m, DefaultTraceFlagsKey, "Expected trace flags to be omitted")
+
+	newTestLogger(buf, WithFormat(FormatW3C)).InfoContext(ctx, "hello")
+	m = decode(t, buf)
+	assert.Equal(t, "00-"+sc.TraceID().String()+"-"+sc.SpanID().String()+"-01", m[DefaultTraceParentKey], "Expected W3C traceparent")
+	assert.NotContains(t, m, DefaultTraceIDKey, "Expected no hex fields in W3C format")
+
+	newTestLogger(buf).InfoContext(context.Background(), "hello")
+	m = decode(t, buf)
+	assert.NotContains(t, m, DefaultTraceIDKey, "Expected no trace fields without a span")
+}
+
+func TestSpanEvents(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	ctx, span := tp.Tracer("test").Start(context.Background(), "op")
+
+	buf := &bytes.Buffer{}
+	logger := newTestLogger(buf, WithSpanEvents(glog.LevelWarn))
+	logger.InfoContext(ctx, "info")
+	logger.WarnContext(ctx, "warn", "user", "alice", "attempt", 3)
+	logger.WithContext(ctx).Error("error")
+	span.End()
+
+	assert.Equal(t, 3, bytes.Count(buf.Bytes(), []byte("\n")), "Expected all entries to be written")
+
+	spans := exporter.GetSpans()
+	if !assert.Len(t, spans, 1, "Expected one exported span") {
+		return
+	}
+	events := spans[0].Events
+	if !assert.Len(t, events, 2, "Expected warn and error span events") {
+		return
+	}
+
+	attrs := attribute.NewSet(events[0].Attributes...)
+	v, _ := attrs.Value(messageKey)
+	assert.Equal(t, "warn", v.AsString(), "Expected message attribute")
+	v, _ = attrs.Value(severityKey)
+	assert.Equal(t, "warn", v.AsString(), "Expected severity attribute")
+	v, _ = attrs.Value("user")
+	assert.Equal(t, "alice", v.AsString(), "Expected field attribute")
+	v, _ = attrs.Value("attempt")
+	assert.Equal(t, int64(3), v.AsInt64(), "Expected int field attribute")
+
+	attrs = attribute.NewSet(events[1].Attributes...)
+	v, _ = attrs.Value(messageKey)
+	assert.Equal(t, "error", v.AsString(), "Expected message attribute of logger bound to context")
+}
+
+func TestSpanEvents_notRecording(t *testing.T) {
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1},
+		SpanID:  trace.SpanID{1},
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+
+	buf := &bytes.Buffer{}
+	newTestLogger(buf, WithSpanEvents(glog.LevelWarn)).ErrorContext(ctx, "error")
+	m := decode(t, buf)
+	assert.Equal(t, sc.TraceID().String(), m[DefaultTraceIDKey], "Expected trace id of remote span context")
+	assert.Equal(t, "00", m[DefaultTraceFlagsKey], "Expected unsampled trace flags")
+}
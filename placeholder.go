@@ -0,0 +1,92 @@
+package glog
+
+import (
+	"fmt"
+	"go.uber.org/zap/zapcore"
+	"strings"
+)
+
+// renderPlaceholders fills the placeholders of msg, such as {user_id}, with
+// the values of the last fields with the same keys, looked up in fields and
+// then in withFields. It reports false if no placeholder was filled and no
+// escape replaced, in which case msg is returned unchanged.
+func renderPlaceholders(msg string, fields, withFields []Field) (string, bool) {
+	if !hasPlaceholders(msg) {
+		return msg, false
+	}
+
+	var b strings.Builder
+	b.Grow(len(msg))
+	found := false
+	for i := 0; i < len(msg); i++ {
+		c := msg[i]
+		switch {
+		case c == '{' && i+1 < len(msg) && msg[i+1] == '{':
+			b.WriteByte('{')
+			i++
+			found = true
+		case c == '}' && i+1 < len(msg) && msg[i+1] == '}':
+			b.WriteByte('}')
+			i++
+			found = true
+		case c == '{':
+			end := strings.IndexAny(msg[i+1:], "{} ")
+			if end <= 0 || msg[i+1+end] != '}' {
+				b.WriteByte(c)
+				continue
+			}
+			key := msg[i+1 : i+1+end]
+			f, ok := lastField(fields, key)
+			if !ok {
+				f, ok = lastField(withFields, key)
+			}
+			if ok {
+				b.WriteString(fieldString(f))
+				found = true
+			} else {
+				b.WriteString(msg[i : i+end+2])
+			}
+			i += end + 1
+		default:
+			b.WriteByte(c)
+		}
+	}
+	if !found {
+		return msg, false
+	}
+	return b.String(), true
+}
+
+// hasPlaceholders reports whether msg may have placeholders or escapes.
+func hasPlaceholders(msg string) bool {
+	return strings.IndexByte(msg, '{') >= 0 || strings.Contains(msg, "}}")
+}
+
+func lastField(fields []Field, key string) (Field, bool) {
+	for i := len(fields) - 1; i >= 0; i-- {
+		if fields[i].Key == key && fields[i].Type != zapcore.SkipType {
+			return fields[i], true
+		}
+	}
+	return Field{}, false
+}
+
+// fieldString renders the value of f as it would be encoded.
+func fieldString(f Field) string {
+	switch f.Type {
+	case zapcore.StringType:
+		return f.String
+	case zapcore.StringerType:
+		return fmt.Sprint(f.Interface)
+	case zapcore.ErrorType:
+		return f.Interface.(error).Error()
+	case zapcore.ObjectMarshalerType:
+		// The fields of Err and NamedErr.
+		if e, ok := f.Interface.(errorObject); ok {
+			return e.err.Error()
+		}
+	}
+	enc := zapcore.NewMapObjectEncoder()
+	f.AddTo(enc)
+	return fmt.Sprint(enc.Fields[f.Key])
+}
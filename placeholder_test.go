@@ -0,0 +1,90 @@
+package glog
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+	"testing"
+	"time"
+)
+
+func TestRenderPlaceholders(t *testing.T) {
+	fields := []Field{
+		String("user_id", "u1"),
+		Int("count", 3),
+		Duration("took", time.Second),
+		Field{Key: "cause", Type: zapcore.ErrorType, Interface: errors.New("boom")},
+		Int("count", 4),
+		Err(errors.New("failed")),
+	}
+	withFields := []Field{String("user_id", "u0"), String("region", "eu")}
+	tests := []struct {
+		msg      string
+		want     string
+		rendered bool
+	}{
+		{"user {user_id} bought {count} items", "user u1 bought 4 items", true},
+		{"took {took}: {cause}", "took 1s: boom", true},
+		{"unknown {missing} stays", "unknown {missing} stays", false},
+		{"{count} in {region}: {error}", "4 in eu: failed", true},
+		{`payload {"a":1}`, `payload {"a":1}`, false},
+		{"escaped {{user_id}} and {{", "escaped {user_id} and {", true},
+		{"no placeholders", "no placeholders", false},
+		{"empty {} and {not closed", "empty {} and {not closed", false},
+		{"spaces { user_id } are not keys", "spaces { user_id } are not keys", false},
+		{"{user_id}", "u1", true},
+	}
+	for _, tt := range tests {
+		got, rendered := renderPlaceholders(tt.msg, fields, withFields)
+		assert.Equal(t, tt.want, got, "Unexpected message for %q", tt.msg)
+		assert.Equal(t, tt.rendered, rendered, "Unexpected result for %q", tt.msg)
+	}
+}
+
+func TestWithMsgPlaceholders(t *testing.T) {
+	core := &mockCore{enabled: true}
+	logger := NewLogger(core, WithMsgPlaceholders(true), WithContextHandlers(BuildContextHandler("request_id")))
+
+	ctx := context.WithValue(context.Background(), "request_id", "r1")
+	logger.InfoContext(ctx, "user {user_id} bought {count} items in {request_id}", "user_id", "u1", "count", 3)
+	if assert.Len(t, core.entries, 1) {
+		assert.Equal(t, "user u1 bought 3 items in r1", core.entries[0].Message)
+	}
+	assert.Equal(t, []Field{
+		Any("request_id", "r1"),
+		Any("user_id", "u1"),
+		Any("count", 3),
+		String("msg_template", "user {user_id} bought {count} items in {request_id}"),
+	}, core.fields, "Expected the fields to be kept and the template to be recorded")
+
+	core.reset()
+	core.enabled = true
+	logger.Info("plain message", "k", "v")
+	assert.Equal(t, []Field{Any("k", "v")}, core.fields, "Expected no template for messages without placeholders")
+
+	core.reset()
+	core.enabled = true
+	logger.Info("unknown {missing}")
+	assert.Empty(t, core.fields, "Expected no template when nothing was replaced")
+
+	core.reset()
+	core.enabled = true
+	logger.With("user_id", "u2").WithContext(ctx).Info("user {user_id} in {request_id}")
+	assert.Equal(t, "user u2 in r1", core.entries[0].Message, "Expected fields of With and WithContext to fill placeholders")
+
+	core.reset()
+	core.enabled = true
+	logger.WithOptions(WithMsgTemplateKey("tpl")).Warn("{n} left", "n", 1)
+	assert.Equal(t, "1 left", core.entries[0].Message)
+	assert.Contains(t, core.fields, String("tpl", "{n} left"))
+
+	assert.PanicsWithValue(t, "lost 2 items", func() {
+		logger.Panic("lost {n} items", "n", 2)
+	}, "Expected the panic value to be the rendered message")
+
+	core.reset()
+	core.enabled = true
+	NewLogger(core).Info("user {user_id}", "user_id", "u1")
+	assert.Equal(t, "user {user_id}", core.entries[0].Message, "Expected placeholders to be opt-in")
+}
@@ -0,0 +1,165 @@
+package rotate
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	CompressNone = ""
+	CompressGzip = "gzip"
+)
+
+type Config struct {
+	Filename       string   `json:"filename" yaml:"filename"`
+	MaxSize        ByteSize `json:"maxSize" yaml:"maxSize"`
+	MaxAge         Duration `json:"maxAge" yaml:"maxAge"`
+	MaxBackups     int      `json:"maxBackups" yaml:"maxBackups"`
+	Compress       string   `json:"compress" yaml:"compress"`
+	Midnight       bool     `json:"midnight" yaml:"midnight"`
+	Interval       Duration `json:"interval" yaml:"interval"`
+	ReopenOnSignal bool     `json:"reopenOnSignal" yaml:"reopenOnSignal"`
+}
+
+func (c *Config) validate() error {
+	if c.Filename == "" {
+		return fmt.Errorf("rotate: empty filename")
+	}
+	switch c.Compress {
+	case CompressNone, CompressGzip:
+	default:
+		return fmt.Errorf("rotate: unsupported compression: %s", c.Compress)
+	}
+	if c.MaxSize < 0 || c.MaxAge < 0 || c.MaxBackups < 0 || c.Interval < 0 {
+		return fmt.Errorf("rotate: negative limit")
+	}
+	return nil
+}
+
+// ParseURL builds a Config from a URL such as
+// rotate:///var/log/app.log?maxSize=100MB&maxAge=7d&maxBackups=10&compress=gzip&reopenOnSignal=true.
+// Relative paths can be written as rotate:app.log.
+func ParseURL(u *url.URL) (cfg Config, err error) {
+	cfg.Filename = u.Path
+	if u.Opaque != "" {
+		cfg.Filename = u.Opaque
+	}
+	if u.Host != "" {
+		return cfg, fmt.Errorf("rotate: URL must not have a host: %s", u)
+	}
+
+	q := u.Query()
+	for key := range q {
+		value := q.Get(key)
+		switch key {
+		case "maxSize":
+			err = cfg.MaxSize.UnmarshalText([]byte(value))
+		case "maxAge":
+			err = cfg.MaxAge.UnmarshalText([]byte(value))
+		case "maxBackups":
+			cfg.MaxBackups, err = strconv.Atoi(value)
+		case "compress":
+			cfg.Compress = value
+		case "midnight":
+			cfg.Midnight, err = strconv.ParseBool(value)
+		case "interval":
+			err = cfg.Interval.UnmarshalText([]byte(value))
+		case "reopenOnSignal":
+			cfg.ReopenOnSignal, err = strconv.ParseBool(value)
+		default:
+			err = fmt.Errorf("rotate: unknown parameter: %s", key)
+		}
+		if err != nil {
+			return
+		}
+	}
+	err = cfg.validate()
+	return
+}
+
+// ByteSize is a size in bytes that can be written as "100MB", "512K" or "1024".
+type ByteSize int64
+
+const (
+	Byte     ByteSize = 1
+	KiloByte          = 1024 * Byte
+	MegaByte          = 1024 * KiloByte
+	GigaByte          = 1024 * MegaByte
+)
+
+var byteSizeUnits = []struct {
+	suffix string
+	size   ByteSize
+}{
+	{"GB", GigaByte},
+	{"MB", MegaByte},
+	{"KB", KiloByte},
+	{"G", GigaByte},
+	{"M", MegaByte},
+	{"K", KiloByte},
+	{"B", Byte},
+}
+
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	s := strings.ToUpper(strings.TrimSpace(string(text)))
+	unit := Byte
+	for _, u := range byteSizeUnits {
+		if strings.HasSuffix(s, u.suffix) {
+			s, unit = strings.TrimSpace(strings.TrimSuffix(s, u.suffix)), u.size
+			break
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("rotate: invalid size: %q", text)
+	}
+	*b = ByteSize(n) * unit
+	return nil
+}
+
+// UnmarshalJSON accepts a plain number of bytes as well as the strings
+// accepted by UnmarshalText.
+func (b *ByteSize) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		return b.UnmarshalText([]byte(s))
+	}
+	n, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return fmt.Errorf("rotate: invalid size: %s", data)
+	}
+	*b = ByteSize(n)
+	return nil
+}
+
+// Duration is a time.Duration that additionally accepts a day suffix, e.g. "7d".
+type Duration time.Duration
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	s := strings.TrimSpace(string(text))
+	if strings.HasSuffix(s, "d") {
+		n, err := strconv.ParseFloat(strings.TrimSuffix(s, "d"), 64)
+		if err != nil {
+			return fmt.Errorf("rotate: invalid duration: %q", text)
+		}
+		*d = Duration(n * float64(24*time.Hour))
+		return nil
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("rotate: invalid duration: %q", text)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
@@ -0,0 +1,35 @@
+package rotate
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// ReopenOnSignal reopens w every time one of sigs is received, SIGHUP by
+// default, so that an external logrotate can move the file away.
+// The returned function stops listening.
+func ReopenOnSignal(w *Writer, sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sigs...)
+	go func() {
+		for {
+			select {
+			case <-ch:
+				_ = w.Reopen()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(ch)
+		close(done)
+	}
+}
@@ -0,0 +1,38 @@
+//go:build !windows && !plan9
+
+package rotate
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestWriter_ReopenOnSignal(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	sink, closeSink, err := zap.Open("rotate://" + filepath.ToSlash(path) + "?reopenOnSignal=true")
+	require.NoError(t, err)
+	defer closeSink()
+
+	_, err = sink.Write([]byte("before\n"))
+	assert.NoError(t, err)
+	require.NoError(t, os.Rename(path, path+".1"))
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, time.Second, 10*time.Millisecond, "Expected the file to be reopened on SIGHUP")
+
+	_, err = sink.Write([]byte("after\n"))
+	assert.NoError(t, err)
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "after\n", string(content))
+}
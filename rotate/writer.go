@@ -0,0 +1,331 @@
+package rotate
+
+import (
+	"compress/gzip"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	Scheme = "rotate"
+
+	backupTimeFormat = "2006-01-02T15-04-05.000"
+	compressSuffix   = ".gz"
+)
+
+func init() {
+	if err := zap.RegisterSink(Scheme, NewSink); err != nil {
+		panic(err)
+	}
+}
+
+// NewSink is the zap sink factory registered under the rotate scheme.
+func NewSink(u *url.URL) (zap.Sink, error) {
+	cfg, err := ParseURL(u)
+	if err != nil {
+		return nil, err
+	}
+	return New(cfg)
+}
+
+// Writer is a file writer that moves the file aside once it grows past
+// MaxSize or when its time window ends, and cleans up old segments in the
+// background.
+type Writer struct {
+	cfg Config
+	now func() time.Time
+
+	mu         sync.Mutex
+	file       *os.File
+	size       int64
+	nextRotate time.Time
+
+	millCh     chan struct{}
+	millDone   chan struct{}
+	stopSignal func()
+	closed     bool
+}
+
+var _ zap.Sink = (*Writer)(nil)
+
+// New opens the file of cfg. If cfg.ReopenOnSignal is set, the file is
+// reopened on SIGHUP until the writer is closed.
+func New(cfg Config) (*Writer, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	w := &Writer{
+		cfg:      cfg,
+		now:      time.Now,
+		millCh:   make(chan struct{}, 1),
+		millDone: make(chan struct{}),
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	go w.mill()
+	if cfg.ReopenOnSignal {
+		w.stopSignal = ReopenOnSignal(w)
+	}
+	return w, nil
+}
+
+func (w *Writer) Write(p []byte) (n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	if w.shouldRotate(int64(len(p))) {
+		if err = w.rotate(); err != nil {
+			return
+		}
+	}
+
+	n, err = w.file.Write(p)
+	w.size += int64(n)
+	return
+}
+
+func (w *Writer) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	return w.file.Sync()
+}
+
+// Close closes the current file and waits for background compression and cleanup to finish.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	err := w.closeFile()
+	close(w.millCh)
+	w.mu.Unlock()
+
+	if w.stopSignal != nil {
+		w.stopSignal()
+	}
+	<-w.millDone
+	return err
+}
+
+// Rotate moves the current file aside and starts a new one.
+func (w *Writer) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return os.ErrClosed
+	}
+	return w.rotate()
+}
+
+// Reopen closes and reopens the file at the configured path, which is needed
+// after an external tool has moved it away.
+func (w *Writer) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return os.ErrClosed
+	}
+	if err := w.closeFile(); err != nil {
+		return err
+	}
+	return w.open()
+}
+
+func (w *Writer) shouldRotate(n int64) bool {
+	if w.cfg.MaxSize > 0 && w.size > 0 && w.size+n > int64(w.cfg.MaxSize) {
+		return true
+	}
+	return !w.nextRotate.IsZero() && !w.now().Before(w.nextRotate)
+}
+
+func (w *Writer) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.cfg.Filename), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(w.cfg.Filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	w.file, w.size = f, info.Size()
+	w.nextRotate = w.nextRotateTime(w.now())
+	return nil
+}
+
+func (w *Writer) closeFile() error {
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+func (w *Writer) rotate() error {
+	if err := w.closeFile(); err != nil {
+		return err
+	}
+	if err := os.Rename(w.cfg.Filename, w.backupName(w.now())); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := w.open(); err != nil {
+		return err
+	}
+
+	select {
+	case w.millCh <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (w *Writer) nextRotateTime(now time.Time) time.Time {
+	var next time.Time
+	if w.cfg.Midnight {
+		y, m, d := now.Date()
+		next = time.Date(y, m, d+1, 0, 0, 0, 0, now.Location())
+	}
+	if w.cfg.Interval > 0 {
+		interval := time.Duration(w.cfg.Interval)
+		if t := now.Truncate(interval).Add(interval); next.IsZero() || t.Before(next) {
+			next = t
+		}
+	}
+	return next
+}
+
+func (w *Writer) splitFilename() (prefix, ext string) {
+	ext = filepath.Ext(w.cfg.Filename)
+	prefix = strings.TrimSuffix(filepath.Base(w.cfg.Filename), ext) + "-"
+	return
+}
+
+func (w *Writer) backupName(t time.Time) string {
+	prefix, ext := w.splitFilename()
+	return filepath.Join(filepath.Dir(w.cfg.Filename), prefix+t.Format(backupTimeFormat)+ext)
+}
+
+// mill compresses and removes old segments each time the file is rotated.
+func (w *Writer) mill() {
+	defer close(w.millDone)
+	for range w.millCh {
+		_ = w.millRun()
+	}
+}
+
+type backup struct {
+	path string
+	t    time.Time
+}
+
+func (w *Writer) backups() ([]backup, error) {
+	dir := filepath.Dir(w.cfg.Filename)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix, ext := w.splitFilename()
+	backups := make([]backup, 0, len(entries))
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		ts := strings.TrimPrefix(strings.TrimSuffix(name, compressSuffix), prefix)
+		if !strings.HasSuffix(ts, ext) {
+			continue
+		}
+		t, err := time.ParseInLocation(backupTimeFormat, strings.TrimSuffix(ts, ext), time.Local)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{path: filepath.Join(dir, name), t: t})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].t.After(backups[j].t)
+	})
+	return backups, nil
+}
+
+func (w *Writer) millRun() error {
+	backups, err := w.backups()
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Time{}
+	if w.cfg.MaxAge > 0 {
+		cutoff = w.now().Add(-time.Duration(w.cfg.MaxAge))
+	}
+
+	for i, b := range backups {
+		if (w.cfg.MaxBackups > 0 && i >= w.cfg.MaxBackups) || (!cutoff.IsZero() && b.t.Before(cutoff)) {
+			if e := os.Remove(b.path); e != nil && err == nil {
+				err = e
+			}
+			continue
+		}
+		if w.cfg.Compress == CompressGzip && !strings.HasSuffix(b.path, compressSuffix) {
+			if e := compressFile(b.path); e != nil && err == nil {
+				err = e
+			}
+		}
+	}
+	return err
+}
+
+func compressFile(path string) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+compressSuffix, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_ = dst.Close()
+			_ = os.Remove(path + compressSuffix)
+		}
+	}()
+
+	gz := gzip.NewWriter(dst)
+	if _, err = io.Copy(gz, src); err != nil {
+		return
+	}
+	if err = gz.Close(); err != nil {
+		return
+	}
+	if err = dst.Close(); err != nil {
+		return
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,250 @@
+package rotate
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+func newTestWriter(t *testing.T, cfg Config) (*Writer, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2024, 10, 1, 12, 0, 0, 0, time.Local)}
+	w, err := New(cfg)
+	require.NoError(t, err)
+	w.now = clock.now
+	w.nextRotate = w.nextRotateTime(clock.now())
+	t.Cleanup(func() { _ = w.Close() })
+	return w, clock
+}
+
+func listDir(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestWriter_RotateBySize(t *testing.T) {
+	dir := t.TempDir()
+	w, clock := newTestWriter(t, Config{
+		Filename: filepath.Join(dir, "app.log"),
+		MaxSize:  10,
+	})
+
+	_, err := w.Write([]byte("12345678\n"))
+	assert.NoError(t, err)
+	clock.add(time.Second)
+	_, err = w.Write([]byte("abc\n"))
+	assert.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, []string{"app-2024-10-01T12-00-01.000.log", "app.log"}, listDir(t, dir))
+	content, err := os.ReadFile(filepath.Join(dir, "app.log"))
+	assert.NoError(t, err)
+	assert.Equal(t, "abc\n", string(content))
+}
+
+func TestWriter_RotateByTime(t *testing.T) {
+	dir := t.TempDir()
+	w, clock := newTestWriter(t, Config{
+		Filename: filepath.Join(dir, "app.log"),
+		Midnight: true,
+	})
+
+	_, err := w.Write([]byte("day 1\n"))
+	assert.NoError(t, err)
+	clock.add(12 * time.Hour)
+	_, err = w.Write([]byte("day 2\n"))
+	assert.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, []string{"app-2024-10-02T00-00-00.000.log", "app.log"}, listDir(t, dir))
+}
+
+func TestWriter_nextRotateTime(t *testing.T) {
+	now := time.Date(2024, 10, 1, 12, 34, 0, 0, time.Local)
+
+	w := &Writer{cfg: Config{Midnight: true}}
+	assert.Equal(t, time.Date(2024, 10, 2, 0, 0, 0, 0, time.Local), w.nextRotateTime(now))
+
+	w = &Writer{cfg: Config{Midnight: true, Interval: Duration(time.Hour)}}
+	assert.Equal(t, now.Truncate(time.Hour).Add(time.Hour), w.nextRotateTime(now))
+
+	w = &Writer{}
+	assert.True(t, w.nextRotateTime(now).IsZero())
+}
+
+func TestWriter_Cleanup(t *testing.T) {
+	dir := t.TempDir()
+	w, clock := newTestWriter(t, Config{
+		Filename:   filepath.Join(dir, "app.log"),
+		MaxBackups: 2,
+		Compress:   CompressGzip,
+	})
+
+	for i := 0; i < 4; i++ {
+		_, err := w.Write([]byte("line\n"))
+		assert.NoError(t, err)
+		clock.add(time.Second)
+		assert.NoError(t, w.Rotate())
+	}
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, []string{
+		"app-2024-10-01T12-00-03.000.log.gz",
+		"app-2024-10-01T12-00-04.000.log.gz",
+		"app.log",
+	}, listDir(t, dir))
+
+	f, err := os.Open(filepath.Join(dir, "app-2024-10-01T12-00-04.000.log.gz"))
+	require.NoError(t, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	content, err := io.ReadAll(gz)
+	assert.NoError(t, err)
+	assert.Equal(t, "line\n", string(content))
+}
+
+func TestWriter_MaxAge(t *testing.T) {
+	dir := t.TempDir()
+	w, clock := newTestWriter(t, Config{
+		Filename: filepath.Join(dir, "app.log"),
+		MaxAge:   Duration(time.Hour),
+	})
+
+	assert.NoError(t, w.Rotate())
+	clock.add(2 * time.Hour)
+	assert.NoError(t, w.Rotate())
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, []string{"app-2024-10-01T14-00-00.000.log", "app.log"}, listDir(t, dir))
+}
+
+func TestWriter_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	w, _ := newTestWriter(t, Config{Filename: path})
+
+	_, err := w.Write([]byte("before\n"))
+	assert.NoError(t, err)
+	require.NoError(t, os.Rename(path, path+".1"))
+	require.NoError(t, w.Reopen())
+	_, err = w.Write([]byte("after\n"))
+	assert.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "after\n", string(content))
+
+	_, err = w.Write([]byte("closed\n"))
+	assert.ErrorIs(t, err, os.ErrClosed)
+}
+
+func TestParseURL(t *testing.T) {
+	u, err := url.Parse("rotate:///var/log/app.log?maxSize=100MB&maxAge=7d&maxBackups=10&compress=gzip&midnight=true&interval=1h")
+	require.NoError(t, err)
+	cfg, err := ParseURL(u)
+	assert.NoError(t, err)
+	assert.Equal(t, Config{
+		Filename:   "/var/log/app.log",
+		MaxSize:    100 * MegaByte,
+		MaxAge:     Duration(7 * 24 * time.Hour),
+		MaxBackups: 10,
+		Compress:   CompressGzip,
+		Midnight:   true,
+		Interval:   Duration(time.Hour),
+	}, cfg)
+
+	u, _ = url.Parse("rotate:app.log")
+	cfg, err = ParseURL(u)
+	assert.NoError(t, err)
+	assert.Equal(t, "app.log", cfg.Filename)
+
+	for _, raw := range []string{
+		"rotate:///app.log?maxSize=abc",
+		"rotate:///app.log?compress=zstd",
+		"rotate:///app.log?unknown=1",
+		"rotate://host/app.log",
+	} {
+		u, _ = url.Parse(raw)
+		_, err = ParseURL(u)
+		assert.Error(t, err, raw)
+	}
+}
+
+func TestByteSize_UnmarshalText(t *testing.T) {
+	tests := map[string]ByteSize{
+		"1024":  1024,
+		"10B":   10,
+		"1k":    KiloByte,
+		"512KB": 512 * KiloByte,
+		"100MB": 100 * MegaByte,
+		"2G":    2 * GigaByte,
+	}
+	for text, want := range tests {
+		var b ByteSize
+		assert.NoError(t, b.UnmarshalText([]byte(text)), text)
+		assert.Equal(t, want, b, text)
+	}
+
+	var b ByteSize
+	assert.Error(t, b.UnmarshalText([]byte("MB")))
+}
+
+func TestByteSize_UnmarshalJSON(t *testing.T) {
+	var cfg Config
+	require.NoError(t, json.Unmarshal([]byte(`{"maxSize": 104857600}`), &cfg))
+	assert.Equal(t, 100*MegaByte, cfg.MaxSize)
+	require.NoError(t, json.Unmarshal([]byte(`{"maxSize": "100MB"}`), &cfg))
+	assert.Equal(t, 100*MegaByte, cfg.MaxSize)
+	assert.Error(t, json.Unmarshal([]byte(`{"maxSize": 1.5}`), &cfg))
+	assert.Error(t, json.Unmarshal([]byte(`{"maxSize": "abc"}`), &cfg))
+}
+
+func TestSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	sink, closeSink, err := zap.Open("rotate://" + filepath.ToSlash(path) + "?maxSize=1KB")
+	require.NoError(t, err)
+
+	_, err = sink.Write([]byte("hello\n"))
+	assert.NoError(t, err)
+	closeSink()
+
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(content), "hello"))
+}
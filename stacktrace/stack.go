@@ -105,6 +105,23 @@ func Take(skip int) string {
 	return stack.String()
 }
 
+// Callers returns the program counters of the stack of the caller, like Take,
+// in a slice that is not pooled and can be kept.
+func Callers(skip int) []uintptr {
+	stack := Capture(skip+1, Full)
+	defer stack.Free()
+
+	return append([]uintptr(nil), stack.pcs...)
+}
+
+// FromPCs returns a Stack of the frames of pcs, as returned by runtime.Callers.
+func FromPCs(pcs []uintptr) *Stack {
+	stack := _stackPool.Get()
+	stack.pcs = append(stack.pcs[:0], pcs...)
+	stack.frames = runtime.CallersFrames(stack.pcs)
+	return stack
+}
+
 type Formatter struct {
 	b        *bytes.Buffer
 	nonEmpty bool
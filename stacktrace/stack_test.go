@@ -31,3 +31,13 @@ func TestTake(t *testing.T) {
 	trace := Take(0)
 	assert.True(t, strings.Contains(trace, "TestTake"), "Expected stack trace to contain function name")
 }
+
+func TestCallers(t *testing.T) {
+	pcs := Callers(0)
+	assert.Greater(t, len(pcs), 0, "Expected callers to contain frames")
+
+	stack := FromPCs(pcs)
+	defer stack.Free()
+	frame, _ := stack.Next()
+	assert.True(t, strings.HasSuffix(frame.Function, "TestCallers"), "Expected the first frame to be the caller: %s", frame.Function)
+}
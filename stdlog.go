@@ -0,0 +1,118 @@
+package glog
+
+import (
+	"bytes"
+	"log"
+	"strings"
+)
+
+// stdLogCallerSkip skips stdLogWriter.Write and the two frames of the
+// standard library logger, log.(*Logger).output and Print, Printf or Println.
+const stdLogCallerSkip = 3
+
+type StdLogOption func(*stdLogWriter)
+
+// StdLogLevel sets the level of the entries written by the standard library
+// logger, LevelInfo by default.
+func StdLogLevel(lvl Level) StdLogOption {
+	return func(w *stdLogWriter) {
+		w.level = lvl
+	}
+}
+
+// StdLogLevelPrefixes makes a leading level in the output, such as
+// "ERROR: msg", "[warn] msg" or "Debug: msg", set the level of the entry
+// instead of the default one. The prefix is removed from the message.
+// Only debug, info, warn, warning, error and err are recognized.
+func StdLogLevelPrefixes() StdLogOption {
+	return func(w *stdLogWriter) {
+		w.levelPrefixes = true
+	}
+}
+
+type stdLogWriter struct {
+	logger        *Logger
+	level         Level
+	levelPrefixes bool
+}
+
+func newStdLogWriter(l *Logger, opts []StdLogOption) *stdLogWriter {
+	w := &stdLogWriter{
+		logger: l.WithOptions(AddCallerSkip(stdLogCallerSkip)),
+		level:  LevelInfo,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+func (w *stdLogWriter) Write(p []byte) (int, error) {
+	msg := string(bytes.TrimSuffix(p, []byte("\n")))
+	lvl := w.level
+	if w.levelPrefixes {
+		if prefixLvl, rest, ok := cutLevelPrefix(msg); ok {
+			lvl, msg = prefixLvl, rest
+		}
+	}
+	w.logger.Log(lvl, msg)
+	return len(p), nil
+}
+
+// cutLevelPrefix parses a leading "LEVEL:" or "[LEVEL]" in s.
+func cutLevelPrefix(s string) (Level, string, bool) {
+	var word, rest string
+	if strings.HasPrefix(s, "[") {
+		end := strings.IndexByte(s, ']')
+		if end < 0 {
+			return 0, s, false
+		}
+		word, rest = s[1:end], s[end+1:]
+	} else {
+		end := strings.IndexByte(s, ':')
+		if end < 0 {
+			return 0, s, false
+		}
+		word, rest = s[:end], s[end+1:]
+	}
+
+	var lvl Level
+	switch strings.ToLower(word) {
+	case "debug":
+		lvl = LevelDebug
+	case "info":
+		lvl = LevelInfo
+	case "warn", "warning":
+		lvl = LevelWarn
+	case "error", "err":
+		lvl = LevelError
+	default:
+		return 0, s, false
+	}
+	return lvl, strings.TrimLeft(rest, " "), true
+}
+
+// NewStdLog returns a standard library logger writing each line to l as an
+// entry, for APIs such as http.Server.ErrorLog. The caller of the entries is
+// the caller of Print, Printf or Println.
+func NewStdLog(l *Logger, opts ...StdLogOption) *log.Logger {
+	return log.New(newStdLogWriter(l, opts), "", 0)
+}
+
+// RedirectStdLog makes the package-level functions of the standard library
+// log package write to l, and returns a function that restores the previous
+// output, prefix and flags.
+func RedirectStdLog(l *Logger, opts ...StdLogOption) func() {
+	flags := log.Flags()
+	prefix := log.Prefix()
+	out := log.Writer()
+
+	log.SetFlags(0)
+	log.SetPrefix("")
+	log.SetOutput(newStdLogWriter(l, opts))
+	return func() {
+		log.SetFlags(flags)
+		log.SetPrefix(prefix)
+		log.SetOutput(out)
+	}
+}
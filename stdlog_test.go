@@ -0,0 +1,94 @@
+package glog
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"log"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewStdLog(t *testing.T) {
+	core := &mockCore{enabled: true}
+	logger := NewLogger(core, AddCaller(), WithName("std"))
+
+	std := NewStdLog(logger)
+	std.Printf("hello %s", "world")
+	std.Println("line")
+
+	if assert.Len(t, core.entries, 2) {
+		assert.Equal(t, "hello world", core.entries[0].Message, "Expected the trailing newline to be removed")
+		assert.Equal(t, LevelInfo, core.entries[0].Level)
+		assert.Equal(t, "std", core.entries[0].LoggerName)
+		assert.Equal(t, "line", core.entries[1].Message)
+		assert.Equal(t, "stdlog_test.go", filepath.Base(core.entries[0].Caller.File), "Expected the caller of Printf")
+	}
+
+	core.entries = nil
+	NewStdLog(logger, StdLogLevel(LevelWarn)).Print("warn")
+	if assert.Len(t, core.entries, 1) {
+		assert.Equal(t, LevelWarn, core.entries[0].Level)
+	}
+}
+
+func TestStdLogLevelPrefixes(t *testing.T) {
+	core := &mockCore{enabled: true}
+	std := NewStdLog(NewLogger(core), StdLogLevelPrefixes())
+
+	tests := []struct {
+		line    string
+		level   Level
+		message string
+	}{
+		{"ERROR: disk full", LevelError, "disk full"},
+		{"[warn] slow", LevelWarn, "slow"},
+		{"Warning: slow", LevelWarn, "slow"},
+		{"debug:details", LevelDebug, "details"},
+		{"http: TLS handshake error", LevelInfo, "http: TLS handshake error"},
+		{"[unterminated", LevelInfo, "[unterminated"},
+		{"no prefix", LevelInfo, "no prefix"},
+	}
+	for _, tt := range tests {
+		core.entries = nil
+		std.Print(tt.line)
+		if assert.Len(t, core.entries, 1) {
+			assert.Equal(t, tt.level, core.entries[0].Level, "Unexpected level for %q", tt.line)
+			assert.Equal(t, tt.message, core.entries[0].Message, "Unexpected message for %q", tt.line)
+		}
+	}
+
+	core.entries = nil
+	NewStdLog(NewLogger(core)).Print("ERROR: kept")
+	assert.Equal(t, LevelInfo, core.entries[0].Level, "Expected prefixes to be ignored by default")
+	assert.Equal(t, "ERROR: kept", core.entries[0].Message)
+}
+
+func TestRedirectStdLog(t *testing.T) {
+	out, prefix, flags := log.Writer(), log.Prefix(), log.Flags()
+	defer func() {
+		log.SetOutput(out)
+		log.SetPrefix(prefix)
+		log.SetFlags(flags)
+	}()
+	buf := &bytes.Buffer{}
+	log.SetOutput(buf)
+	log.SetPrefix("app: ")
+	log.SetFlags(log.Lshortfile)
+
+	core := &mockCore{enabled: true}
+	undo := RedirectStdLog(NewLogger(core, AddCaller()), StdLogLevel(LevelError))
+	log.Printf("redirected %d", 1)
+	if assert.Len(t, core.entries, 1) {
+		assert.Equal(t, "redirected 1", core.entries[0].Message, "Expected no prefix or flags")
+		assert.Equal(t, LevelError, core.entries[0].Level)
+		assert.Equal(t, "stdlog_test.go", filepath.Base(core.entries[0].Caller.File), "Expected the caller of log.Printf")
+	}
+	assert.Zero(t, buf.Len())
+
+	undo()
+	log.Print("restored")
+	assert.Len(t, core.entries, 1)
+	assert.Equal(t, "app: ", log.Prefix())
+	assert.Equal(t, log.Lshortfile, log.Flags())
+	assert.Contains(t, buf.String(), "app: stdlog_test.go:")
+}